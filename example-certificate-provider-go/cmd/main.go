@@ -3,5 +3,5 @@ package main
 import "certificate-provider/internal/job"
 
 func main() {
-	job.Run()
+	job.Run(job.NewCertificateProvider())
 }
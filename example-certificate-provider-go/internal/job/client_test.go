@@ -0,0 +1,223 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigFromEnvAuthType tests that the authentication method is selected from the environment variables that are set
+func TestConfigFromEnvAuthType(t *testing.T) {
+	testCases := []struct {
+		name             string
+		env              map[string]string
+		expectedAuthType string
+		expectedError    string
+	}{
+		{
+			name:             "API key",
+			env:              map[string]string{},
+			expectedAuthType: core.AUTHTYPE_IAM,
+		},
+		{
+			name:             "Trusted profile ID without API key",
+			env:              map[string]string{"SM_ACCESS_APIKEY": "", "SM_TRUSTED_PROFILE_ID": "Profile-1234"},
+			expectedAuthType: core.AUTHTYPE_CONTAINER,
+		},
+		{
+			name:             "API key takes precedence over the trusted profile",
+			env:              map[string]string{"SM_TRUSTED_PROFILE_NAME": "my-profile"},
+			expectedAuthType: core.AUTHTYPE_IAM,
+		},
+		{
+			name:             "VPC instance with the linked trusted profile",
+			env:              map[string]string{"SM_ACCESS_APIKEY": "", "SM_AUTH_TYPE": "vpc"},
+			expectedAuthType: core.AUTHTYPE_VPC,
+		},
+		{
+			name:          "No authentication method",
+			env:           map[string]string{"SM_ACCESS_APIKEY": ""},
+			expectedError: "environment variable SM_ACCESS_APIKEY is required but not set",
+		},
+		{
+			name:          "Container without trusted profile",
+			env:           map[string]string{"SM_AUTH_TYPE": "container"},
+			expectedError: "SM_TRUSTED_PROFILE_ID or SM_TRUSTED_PROFILE_NAME is required to authenticate with a trusted profile",
+		},
+		{
+			name:          "Trusted profile ID and name",
+			env:           map[string]string{"SM_AUTH_TYPE": "container", "SM_TRUSTED_PROFILE_ID": "Profile-1234", "SM_TRUSTED_PROFILE_NAME": "my-profile"},
+			expectedError: "SM_TRUSTED_PROFILE_ID and SM_TRUSTED_PROFILE_NAME cannot be both set",
+		},
+		{
+			name:          "Unknown authentication method",
+			env:           map[string]string{"SM_AUTH_TYPE": "basic"},
+			expectedError: "invalid value 'basic' for SM_AUTH_TYPE. allowed values are: iam, container, vpc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := ConfigFromEnv()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAuthType, config.SM_AUTH_TYPE)
+		})
+	}
+}
+
+// TestIAMURL tests that the IAM URL matches the environment and the network of the Secrets Manager instance
+func TestIAMURL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		expectedURL string
+	}{
+		{
+			name:        "Public instance",
+			config:      Config{SM_INSTANCE_URL: "https://1234.us-south.secrets-manager.appdomain.cloud"},
+			expectedURL: "https://iam.cloud.ibm.com",
+		},
+		{
+			name:        "Private instance",
+			config:      Config{SM_INSTANCE_URL: "https://1234.private.us-south.secrets-manager.appdomain.cloud"},
+			expectedURL: "https://private.iam.cloud.ibm.com",
+		},
+		{
+			name:        "Test instance",
+			config:      Config{SM_INSTANCE_URL: "https://1234.us-south.secrets-manager.test.appdomain.cloud"},
+			expectedURL: "https://iam.test.cloud.ibm.com",
+		},
+		{
+			name:        "Private test instance",
+			config:      Config{SM_INSTANCE_URL: "https://1234.private.us-south.secrets-manager.test.appdomain.cloud/api"},
+			expectedURL: "https://private.iam.test.cloud.ibm.com",
+		},
+		{
+			name:        "Override",
+			config:      Config{SM_INSTANCE_URL: "https://1234.private.us-south.secrets-manager.appdomain.cloud", SM_IAM_URL: "https://iam.vpe.example.com/"},
+			expectedURL: "https://iam.vpe.example.com",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedURL, IAMURL(&tc.config))
+		})
+	}
+}
+
+// TestConfigFromEnvIAMURL tests that SM_IAM_URL must be an absolute URL
+func TestConfigFromEnvIAMURL(t *testing.T) {
+	setCommonEnv(t)
+	t.Setenv("SM_IAM_URL", "private.iam.cloud.ibm.com")
+
+	_, err := ConfigFromEnv()
+
+	assert.ErrorContains(t, err, "invalid value 'private.iam.cloud.ibm.com' for SM_IAM_URL. must be an absolute URL")
+}
+
+// newTokenServer starts a stand-in for the IAM token service and the VPC instance metadata service, which issues
+// access tokens named after the grant or the trusted profile of the request
+func newTokenServer(t *testing.T) *httptest.Server {
+	writeToken := func(w http.ResponseWriter, accessToken string) {
+		now := time.Now()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"expiration":   now.Add(time.Hour).Unix(),
+			"created_at":   now.UTC().Format(time.RFC3339),
+			"expires_at":   now.Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /identity/token", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("grant_type") {
+		case "urn:ibm:params:oauth:grant-type:apikey":
+			writeToken(w, "apikey-token-"+r.FormValue("apikey"))
+		case "urn:ibm:params:oauth:grant-type:cr-token":
+			writeToken(w, "cr-token-"+r.FormValue("cr_token")+"-"+r.FormValue("profile_id"))
+		default:
+			http.Error(w, "unsupported grant type", http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("PUT /instance_identity/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		writeToken(w, "instance-identity-token")
+	})
+	mux.HandleFunc("POST /instance_identity/v1/iam_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer instance-identity-token" {
+			http.Error(w, "invalid instance identity token", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			TrustedProfile struct {
+				Name string `json:"name"`
+			} `json:"trusted_profile"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		writeToken(w, "vpc-token-"+body.TrustedProfile.Name)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestNewAuthenticator tests that the selected authenticator obtains its access token from the stand-in token services
+func TestNewAuthenticator(t *testing.T) {
+	server := newTokenServer(t)
+	crTokenFilename := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(crTokenFilename, []byte("compute-resource"), 0600))
+
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedToken string
+	}{
+		{
+			name:          "API key",
+			config:        Config{SM_AUTH_TYPE: core.AUTHTYPE_IAM, SM_ACCESS_APIKEY: "test-apikey"},
+			expectedToken: "apikey-token-test-apikey",
+		},
+		{
+			name:          "Trusted profile with the compute resource token",
+			config:        Config{SM_AUTH_TYPE: core.AUTHTYPE_CONTAINER, SM_TRUSTED_PROFILE_ID: "Profile-1234", SM_CR_TOKEN_FILENAME: crTokenFilename},
+			expectedToken: "cr-token-compute-resource-Profile-1234",
+		},
+		{
+			name:          "VPC instance identity",
+			config:        Config{SM_AUTH_TYPE: core.AUTHTYPE_VPC, SM_TRUSTED_PROFILE_NAME: "my-profile"},
+			expectedToken: "vpc-token-my-profile",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator, err := newAuthenticator(tc.config, server.URL, server.URL)
+			assert.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "https://test-instance.us-south.secrets-manager.appdomain.cloud", nil)
+			assert.NoError(t, authenticator.Authenticate(request))
+			assert.Equal(t, "Bearer "+tc.expectedToken, request.Header.Get("Authorization"))
+		})
+	}
+}
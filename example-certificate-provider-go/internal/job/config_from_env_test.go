@@ -0,0 +1,390 @@
+package job
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setCommonEnv sets the environment variables that Secrets Manager passes to every job run
+func setCommonEnv(t *testing.T) {
+	t.Setenv("SM_ACCESS_APIKEY", "test-apikey")
+	t.Setenv("SM_INSTANCE_URL", "https://test-instance.us-south.secrets-manager.appdomain.cloud")
+	t.Setenv("SM_SECRET_GROUP_ID", "test-secret-group-id")
+	t.Setenv("SM_SECRET_NAME", "test-secret-name")
+	t.Setenv("SM_SECRET_TASK_ID", "test-secret-task-id")
+	t.Setenv("SM_SECRET_ID", "test-secret-id")
+	t.Setenv("SM_ACTION", sm.SecretTask_Type_CreateCredentials)
+	t.Setenv("SM_TRIGGER", "secret_creation")
+	t.Setenv("SM_COMMON_NAME_VALUE", "test.example.com")
+}
+
+// TestConfigFromEnvEnums tests that enum input variables are parsed into their typed values
+func TestConfigFromEnvEnums(t *testing.T) {
+	testCases := []struct {
+		name             string
+		keyAlgo          string
+		signAlgo         string
+		expectedKeyAlgo  KeyAlgo
+		expectedSignAlgo SignAlgo
+		expectedError    string
+	}{
+		{
+			name:             "Valid values",
+			keyAlgo:          "ECDSA",
+			signAlgo:         "SHA512",
+			expectedKeyAlgo:  KeyAlgoECDSA,
+			expectedSignAlgo: SignAlgoSHA512,
+		},
+		{
+			name:             "Values not set",
+			keyAlgo:          "",
+			signAlgo:         "",
+			expectedKeyAlgo:  KeyAlgoRSA,
+			expectedSignAlgo: SignAlgoSHA256,
+		},
+		{
+			name:          "Invalid key algorithm",
+			keyAlgo:       "FOO",
+			signAlgo:      "SHA256",
+			expectedError: "invalid value 'FOO' for SMIN_KEY_ALGO. allowed values are: RSA, ECDSA",
+		},
+		{
+			name:          "Values are case sensitive",
+			keyAlgo:       "RSA",
+			signAlgo:      "sha256",
+			expectedError: "invalid value 'sha256' for SMIN_SIGN_ALGO. allowed values are: SHA256, SHA512",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			t.Setenv("SM_KEY_ALGO_VALUE", tc.keyAlgo)
+			t.Setenv("SM_SIGN_ALGO_VALUE", tc.signAlgo)
+
+			config, err := ConfigFromEnv()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedKeyAlgo, config.SM_KEY_ALGO)
+			assert.Equal(t, tc.expectedSignAlgo, config.SM_SIGN_ALGO)
+		})
+	}
+}
+
+// TestConfigFromEnvTrigger tests that SM_TRIGGER is parsed into a Trigger and that unknown triggers are kept
+func TestConfigFromEnvTrigger(t *testing.T) {
+	testCases := []struct {
+		name            string
+		trigger         string
+		expectedTrigger Trigger
+		expectedValid   bool
+	}{
+		{
+			name:            "Secret creation",
+			trigger:         "secret_creation",
+			expectedTrigger: TriggerSecretCreation,
+			expectedValid:   true,
+		},
+		{
+			name:            "Secret version expiration",
+			trigger:         "secret_version_expiration",
+			expectedTrigger: TriggerSecretVersionExpiration,
+			expectedValid:   true,
+		},
+		{
+			name:            "Trigger added by a later Secrets Manager release",
+			trigger:         "secret_import",
+			expectedTrigger: Trigger("secret_import"),
+			expectedValid:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			t.Setenv("SM_TRIGGER", tc.trigger)
+
+			config, err := ConfigFromEnv()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTrigger, config.SM_TRIGGER)
+			assert.Equal(t, tc.expectedValid, config.SM_TRIGGER.IsValid())
+		})
+	}
+}
+
+// TestConfigFromEnvDefaults tests that the default values declared in job_config.json are applied
+func TestConfigFromEnvDefaults(t *testing.T) {
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		expectedConfig Config
+	}{
+		{
+			name: "All values set",
+			env: map[string]string{
+				"SM_EXPIRATION_DAYS_VALUE": "30",
+				"SM_KEY_ALGO_VALUE":        "ECDSA",
+				"SM_SIGN_ALGO_VALUE":       "SHA512",
+			},
+			expectedConfig: Config{
+				SM_EXPIRATION_DAYS: 30,
+				SM_KEY_ALGO:        KeyAlgoECDSA,
+				SM_SIGN_ALGO:       SignAlgoSHA512,
+			},
+		},
+		{
+			name: "No values set",
+			env:  map[string]string{},
+			expectedConfig: Config{
+				SM_EXPIRATION_DAYS: 90,
+				SM_KEY_ALGO:        KeyAlgoRSA,
+				SM_SIGN_ALGO:       SignAlgoSHA256,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := ConfigFromEnv()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConfig.SM_EXPIRATION_DAYS, config.SM_EXPIRATION_DAYS)
+			assert.Equal(t, tc.expectedConfig.SM_KEY_ALGO, config.SM_KEY_ALGO)
+			assert.Equal(t, tc.expectedConfig.SM_SIGN_ALGO, config.SM_SIGN_ALGO)
+		})
+	}
+}
+
+// TestConfigFromEnvConstraints tests that the range, length and pattern constraints declared in job_config.json are enforced
+func TestConfigFromEnvConstraints(t *testing.T) {
+	testCases := []struct {
+		name          string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name: "Valid values",
+			env: map[string]string{
+				"SM_EXPIRATION_DAYS_VALUE": "825",
+				"SM_COUNTRY_VALUE":         "US",
+			},
+		},
+		{
+			name: "Expiration days below minimum",
+			env: map[string]string{
+				"SM_EXPIRATION_DAYS_VALUE": "-5",
+			},
+			expectedError: "invalid value '-5' for SMIN_EXPIRATION_DAYS. must be at least 1",
+		},
+		{
+			name: "Expiration days above maximum",
+			env: map[string]string{
+				"SM_EXPIRATION_DAYS_VALUE": "1000",
+			},
+			expectedError: "invalid value '1000' for SMIN_EXPIRATION_DAYS. must be at most 825",
+		},
+		{
+			name: "Country does not match pattern",
+			env: map[string]string{
+				"SM_COUNTRY_VALUE": "usa",
+			},
+			expectedError: "invalid value 'usa' for SMIN_COUNTRY. must match the pattern '^[A-Z]{2}$'",
+		},
+		{
+			name: "Common name too long",
+			env: map[string]string{
+				"SM_COMMON_NAME_VALUE": strings.Repeat("a", 65),
+			},
+			expectedError: "for SMIN_COMMON_NAME. must be at most 64 characters long",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := ConfigFromEnv()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestConfigValidateTags tests that the validate tags of the Config fields check the declared constraints
+func TestConfigValidateTags(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedError string
+	}{
+		{
+			name:   "Optional values not set",
+			config: Config{SM_COMMON_NAME: "example.com"},
+		},
+		{
+			name:   "Valid values",
+			config: Config{SM_COMMON_NAME: "example.com", SM_EXPIRATION_DAYS: 825, SM_COUNTRY: "US"},
+		},
+		{
+			name:          "Expiration days above maximum",
+			config:        Config{SM_COMMON_NAME: "example.com", SM_EXPIRATION_DAYS: 1000},
+			expectedError: "SM_EXPIRATION_DAYS",
+		},
+		{
+			name:          "Country does not match pattern",
+			config:        Config{SM_COMMON_NAME: "example.com", SM_COUNTRY: "usa"},
+			expectedError: "SM_COUNTRY",
+		},
+		{
+			name:          "Common name too long",
+			config:        Config{SM_COMMON_NAME: strings.Repeat("a", 65)},
+			expectedError: "SM_COMMON_NAME",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate.Struct(tc.config)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestConfigFromEnvConfigError tests that all invalid values are collected in a ConfigError
+func TestConfigFromEnvConfigError(t *testing.T) {
+	setCommonEnv(t)
+	t.Setenv("SM_SECRET_TASK_ID", "")
+	t.Setenv("SM_EXPIRATION_DAYS_VALUE", "ninety")
+	t.Setenv("SM_KEY_ALGO_VALUE", "DSA")
+
+	_, err := ConfigFromEnv()
+
+	var configErr *ConfigError
+	if assert.ErrorAs(t, err, &configErr) {
+		assert.Equal(t, []ConfigFieldError{
+			{Variable: "SM_SECRET_TASK_ID", Value: "", Message: "environment variable SM_SECRET_TASK_ID is required but not set"},
+			{Variable: "SMIN_EXPIRATION_DAYS", Value: "ninety", Message: "invalid value 'ninety' for SMIN_EXPIRATION_DAYS. must be an integer"},
+			{Variable: "SMIN_KEY_ALGO", Value: "DSA", Message: "invalid value 'DSA' for SMIN_KEY_ALGO. allowed values are: RSA, ECDSA"},
+		}, configErr.Errors)
+	}
+	assert.ErrorContains(t, err, "configuration errors: environment variable SM_SECRET_TASK_ID is required but not set; invalid value 'ninety'")
+}
+
+// TestConfigFromEnvJobTimeout tests that SM_JOB_TIMEOUT is parsed as a number of seconds
+func TestConfigFromEnvJobTimeout(t *testing.T) {
+	testCases := []struct {
+		name            string
+		value           string
+		expectedTimeout time.Duration
+		expectedError   string
+	}{
+		{
+			name:            "Not set",
+			value:           "",
+			expectedTimeout: DefaultJobTimeout,
+		},
+		{
+			name:            "Seconds",
+			value:           "600",
+			expectedTimeout: 10 * time.Minute,
+		},
+		{
+			name:          "Not more than the time reserved to report the result",
+			value:         "30",
+			expectedError: "invalid value '30' for SM_JOB_TIMEOUT. must be a number of seconds greater than 30",
+		},
+		{
+			name:          "Duration",
+			value:         "10m",
+			expectedError: "invalid value '10m' for SM_JOB_TIMEOUT",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			t.Setenv("SM_JOB_TIMEOUT", tc.value)
+
+			config, err := ConfigFromEnv()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTimeout, config.SM_JOB_TIMEOUT)
+		})
+	}
+}
+
+// TestConfigFromEnvTaskUpdateRetries tests that the limits of the task update retries are read from the environment
+func TestConfigFromEnvTaskUpdateRetries(t *testing.T) {
+	testCases := []struct {
+		name                string
+		maxAttempts         string
+		maxBackoff          string
+		expectedMaxAttempts int
+		expectedMaxBackoff  time.Duration
+		expectedError       string
+	}{
+		{
+			name: "Not set",
+		},
+		{
+			name:                "Set",
+			maxAttempts:         "10",
+			maxBackoff:          "60",
+			expectedMaxAttempts: 10,
+			expectedMaxBackoff:  time.Minute,
+		},
+		{
+			name:          "Zero attempts",
+			maxAttempts:   "0",
+			expectedError: "invalid value '0' for SM_TASK_UPDATE_MAX_ATTEMPTS. must be a positive integer",
+		},
+		{
+			name:          "Backoff as a duration",
+			maxBackoff:    "1m",
+			expectedError: "invalid value '1m' for SM_TASK_UPDATE_MAX_BACKOFF. must be a positive number of seconds",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setCommonEnv(t)
+			t.Setenv("SM_TASK_UPDATE_MAX_ATTEMPTS", tc.maxAttempts)
+			t.Setenv("SM_TASK_UPDATE_MAX_BACKOFF", tc.maxBackoff)
+
+			config, err := ConfigFromEnv()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMaxAttempts, config.SM_TASK_UPDATE_MAX_ATTEMPTS)
+			assert.Equal(t, tc.expectedMaxBackoff, config.SM_TASK_UPDATE_MAX_BACKOFF)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setDryRunEnv writes an env file with the given lines for RunDryRun and clears the variables that a dry run sets
+// when the test ends
+func setDryRunEnv(t *testing.T, lines ...string) {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(envFile, []byte(strings.Join(lines, "\n")), 0o600))
+	t.Setenv("SM_DRY_RUN_ENV_FILE", envFile)
+	t.Setenv("SM_DRY_RUN_FIXTURES", t.TempDir())
+	for key := range dryRunDefaults {
+		t.Setenv(key, "")
+	}
+	for _, line := range lines {
+		if key, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "="); ok && !strings.HasPrefix(key, "#") {
+			t.Setenv(key, "")
+		}
+	}
+}
+
+// TestRunDryRun tests that a dry run writes the credentials and the task update instead of sending them
+func TestRunDryRun(t *testing.T) {
+	t.Run("Create credentials", func(t *testing.T) {
+		setDryRunEnv(t,
+			"# certificate of the dry run",
+			"SM_ACTION=create_credentials",
+			"SM_TRIGGER='secret_creation'",
+			"",
+			`export SM_COMMON_NAME_VALUE="dry-run.example.com"`,
+		)
+		var out bytes.Buffer
+
+		err := RunDryRun(&out, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "Credentials payload with credentials ID '")
+		assert.Contains(t, out.String(), "PUT https://dry-run.invalid/api/v2/secrets/dry-run-secret-id/tasks/dry-run-task-id (not sent):\n{\n")
+		assert.Contains(t, out.String(), `"status": "credentials_created"`)
+		assert.Contains(t, out.String(), `"private_key_base64": "[REDACTED]"`)
+		assert.NotContains(t, out.String(), "LS0t", "the base64 PEM blocks should be redacted")
+	})
+
+	t.Run("Invalid configuration fails the task", func(t *testing.T) {
+		setDryRunEnv(t, "SM_ACTION=create_credentials", "SM_TRIGGER=secret_creation")
+		var out bytes.Buffer
+
+		err := RunDryRun(&out, NewCertificateProvider())
+
+		assert.Error(t, err)
+		assert.Contains(t, out.String(), `"status": "failed"`)
+		assert.Contains(t, out.String(), `"code": "`+ErrInvalidJobConfig+`"`)
+	})
+
+	t.Run("Variables of the environment take precedence", func(t *testing.T) {
+		setDryRunEnv(t, "SM_ACTION=create_credentials", "SM_TRIGGER=secret_creation", "SM_COMMON_NAME_VALUE=dry-run.example.com")
+		t.Setenv("SM_SECRET_ID", "my-secret-id")
+		var out bytes.Buffer
+
+		err := RunDryRun(&out, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "/api/v2/secrets/my-secret-id/tasks/dry-run-task-id")
+	})
+
+	t.Run("Missing env file", func(t *testing.T) {
+		setDryRunEnv(t)
+		t.Setenv("SM_DRY_RUN_ENV_FILE", filepath.Join(t.TempDir(), "missing.env"))
+
+		err := RunDryRun(&bytes.Buffer{}, NewCertificateProvider())
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Invalid env file", func(t *testing.T) {
+		setDryRunEnv(t, "SM_ACTION")
+
+		err := RunDryRun(&bytes.Buffer{}, NewCertificateProvider())
+
+		assert.ErrorContains(t, err, "invalid line 1 in env file")
+	})
+}
+
+// TestDryRunClient tests that the secrets of a dry run are read from the fixture files
+func TestDryRunClient(t *testing.T) {
+	fixturesDir := t.TempDir()
+	fixture := `{"id": "login-secret-id", "name": "login", "secret_type": "arbitrary", "payload": "s3cr3t"}`
+	assert.NoError(t, os.WriteFile(filepath.Join(fixturesDir, "login-secret-id.json"), []byte(fixture), 0o600))
+	client := NewDryRunClient(&bytes.Buffer{}, "https://dry-run.invalid", fixturesDir)
+
+	secret, err := GetSecret(context.Background(), client, "login-secret-id")
+
+	assert.NoError(t, err)
+	if assert.IsType(t, &sm.ArbitrarySecret{}, secret) {
+		assert.Equal(t, "s3cr3t", core.StringNilMapper(secret.(*sm.ArbitrarySecret).Payload))
+	}
+
+	for _, id := range []string{"unknown-secret-id", "../login-secret-id"} {
+		_, response, err := client.GetSecretWithContext(context.Background(), &sm.GetSecretOptions{ID: core.StringPtr(id)})
+		assert.ErrorContains(t, err, "not found: no fixture file")
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	}
+}
@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTaskErrors tests that TaskErrors keeps the code and remediation of each accumulated error
+func TestTaskErrors(t *testing.T) {
+	var errs TaskErrors
+	assert.NoError(t, errs.Err())
+
+	notFound := errors.New("schema not found")
+	errs.Add(nil)
+	errs.Add(TaskErrorf("Err10001", "cannot connect to the database"))
+	errs.Add(WithRemediation(NewTaskError("Err10002", notFound), "create the schema first"))
+	errs.Add(errors.New("cannot close the connection"))
+
+	other := TaskErrors{}
+	other.Add(TaskErrorf("Err10003", "cannot drop role"))
+	errs.Add(other.Err())
+
+	assert.Equal(t, 4, errs.Len())
+	assert.EqualError(t, errs.Err(), "cannot connect to the database; schema not found; cannot close the connection; cannot drop role")
+	assert.ErrorIs(t, errs.Err(), notFound)
+	assert.Equal(t, "Err10001", ErrorCode(errs.Err(), ErrCredentialsNotCreated))
+	assert.Equal(t, ErrCredentialsNotCreated, ErrorCode(WithRemediation(errors.New("no code"), "retry"), ErrCredentialsNotCreated))
+}
+
+// TestErrorCatalog tests the error constructors generated from error_codes.json
+func TestErrorCatalog(t *testing.T) {
+	cause := errors.New("x509: unsupported public key type")
+	err := NewCertificateNotCreatedError("42", cause)
+
+	assert.EqualError(t, err, "cannot create certificate with serial number: '42'. error: x509: unsupported public key type")
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, ErrCertificateNotCreated, ErrorCode(err, ErrCredentialsNotCreated))
+	assert.Equal(t, ErrorCategoryConfig, ErrorCatalog[ErrCertificateNotCreated].Category)
+	assert.NoError(t, NewCertificateNotCreatedError("42", nil))
+
+	assert.False(t, IsRetryable(err))
+	assert.True(t, IsRetryable(NewRSAKeyNotGeneratedError(cause)))
+	assert.True(t, IsRetryable(NewTaskError(ErrJobTimeout, context.DeadlineExceeded)))
+	assert.False(t, IsRetryable(cause))
+}
@@ -0,0 +1,159 @@
+package job
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServerConfig starts a FakeSecretsManagerServer and returns it with the config of a secret task on it
+func newFakeServerConfig(t *testing.T, action string) (*FakeSecretsManagerServer, Config) {
+	server := NewFakeSecretsManagerServer()
+	t.Cleanup(server.Close)
+	config := Config{
+		SM_ACTION:          action,
+		SM_SECRET_ID:       "test-secret-id",
+		SM_SECRET_TASK_ID:  "test-secret-task-id",
+		SM_CREDENTIALS_ID:  "test-credentials-id",
+		SM_COMMON_NAME:     "test.example.com",
+		SM_EXPIRATION_DAYS: 30,
+	}
+	server.Configure(&config)
+	return server, config
+}
+
+// TestFakeSecretsManagerServer tests the job end to end with the SDK client against FakeSecretsManagerServer
+func TestFakeSecretsManagerServer(t *testing.T) {
+	t.Run("Create credentials", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_CreateCredentials)
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+
+		err = RunTask(context.Background(), client, &config, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		assert.Equal(t, sm.SecretTask_Status_CredentialsCreated, server.TaskStatus("test-secret-id", "test-secret-task-id"))
+		updates := server.TaskUpdates()
+		if assert.Len(t, updates, 1) {
+			assert.Empty(t, updates[0].From)
+			assert.Equal(t, config.SM_CREDENTIALS_ID, updates[0].CredentialsID)
+			assert.NotEmpty(t, updates[0].Credentials["certificate_base64"])
+			assert.NotEmpty(t, updates[0].Credentials["private_key_base64"])
+		}
+		assert.Equal(t, 1, server.RequestCount(FakeEndpointIAMToken))
+	})
+
+	t.Run("Delete credentials", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_DeleteCredentials)
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+
+		err = RunTask(context.Background(), client, &config, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		assert.Equal(t, sm.SecretTask_Status_CredentialsDeleted, server.TaskStatus("test-secret-id", "test-secret-task-id"))
+	})
+
+	t.Run("Task update retried after 429 and 500", func(t *testing.T) {
+		setFastRetries(t)
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_DeleteCredentials)
+		server.FailNext(FakeEndpointReplaceSecretTask,
+			FakeFailure{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"0"}}},
+			FakeFailure{StatusCode: http.StatusInternalServerError})
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+
+		err = RunTask(context.Background(), client, &config, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		var statusCodes []int
+		for _, request := range server.Requests() {
+			if request.Endpoint == FakeEndpointReplaceSecretTask {
+				statusCodes = append(statusCodes, request.StatusCode)
+			}
+		}
+		assert.Equal(t, []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusOK}, statusCodes)
+		assert.Len(t, server.TaskUpdates(), 1)
+	})
+
+	t.Run("Task update timed out", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_CreateCredentials)
+		server.FailNext(FakeEndpointReplaceSecretTask, FakeFailure{Delay: time.Minute})
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err = RunTask(ctx, client, &config, NewCertificateProvider())
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		updates := server.TaskUpdates()
+		if assert.Len(t, updates, 1) {
+			assert.Equal(t, sm.SecretTask_Status_Failed, updates[0].To)
+			assert.Equal(t, ErrJobTimeout, updates[0].Errors[0].Code)
+		}
+	})
+
+	t.Run("Task already in a final state", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_DeleteCredentials)
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+		_, err = UpdateTaskAboutCredentialsDeleted(context.Background(), client, &config)
+		assert.NoError(t, err)
+
+		_, err = UpdateTaskAboutError(context.Background(), client, &config, ErrCredentialsNotDeleted, "cannot delete credentials")
+
+		assert.ErrorContains(t, err, "already in a final state")
+		assert.Equal(t, sm.SecretTask_Status_CredentialsDeleted, server.TaskStatus("test-secret-id", "test-secret-task-id"))
+		assert.Len(t, server.TaskUpdates(), 1)
+	})
+
+	t.Run("Secrets", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_CreateCredentials)
+		server.AddSecret("arbitrary-id", NewMockArbitrarySecret("arbitrary-id", "s3cr3t")).
+			AddSecret("custom-id", NewMockCustomCredentialsSecret("custom-id", map[string]interface{}{"token": "custom-token"})).
+			AddSecret("service-id", NewMockServiceCredentialsSecret("service-id", map[string]interface{}{"apikey": "service-apikey"}))
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+
+		secret, err := GetSecret(context.Background(), client, "arbitrary-id")
+		assert.NoError(t, err)
+		if assert.IsType(t, &sm.ArbitrarySecret{}, secret) {
+			assert.Equal(t, "s3cr3t", core.StringNilMapper(secret.(*sm.ArbitrarySecret).Payload))
+		}
+
+		secret, err = GetSecret(context.Background(), client, "custom-id")
+		assert.NoError(t, err)
+		if assert.IsType(t, &sm.CustomCredentialsSecret{}, secret) {
+			assert.Equal(t, "custom-token", secret.(*sm.CustomCredentialsSecret).CredentialsContent["token"])
+		}
+
+		secret, err = GetSecret(context.Background(), client, "service-id")
+		assert.NoError(t, err)
+		if assert.IsType(t, &sm.ServiceCredentialsSecret{}, secret) {
+			assert.Equal(t, "service-apikey", core.StringNilMapper(secret.(*sm.ServiceCredentialsSecret).Credentials.Apikey))
+		}
+
+		_, err = GetSecret(context.Background(), client, "unknown-id")
+		assert.ErrorContains(t, err, "secret with ID 'unknown-id' not found")
+		assert.Equal(t, 1, server.RequestCount(FakeEndpointIAMToken))
+	})
+
+	t.Run("Invalid API key", func(t *testing.T) {
+		server, config := newFakeServerConfig(t, sm.SecretTask_Type_CreateCredentials)
+		config.SM_ACCESS_APIKEY = "invalid-apikey"
+		client, err := NewSecretsManagerClient(config)
+		assert.NoError(t, err)
+
+		_, err = GetSecret(context.Background(), client, "arbitrary-id")
+
+		assert.ErrorContains(t, err, "provided API key could not be found")
+		assert.Equal(t, 0, server.RequestCount(FakeEndpointGetSecret))
+	})
+}
@@ -0,0 +1,392 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProvider is a CredentialsProvider that returns fixed results and counts the Delete calls
+type stubProvider struct {
+	payload     CredentialsPayload
+	createErr   error
+	deleteErr   error
+	deleteCalls int
+	blocking    bool // Create blocks until the context is done, then returns createErr or the error of the context
+	createPanic any  // Create panics with the value if set
+	deletePanic any  // Delete panics with the value if set
+}
+
+func (p *stubProvider) Create(ctx context.Context, client SecretsManagerClient, config *Config) (CredentialsPayload, string, error) {
+	if p.blocking {
+		<-ctx.Done()
+		if p.createErr != nil {
+			return CredentialsPayload{}, "", p.createErr
+		}
+		return CredentialsPayload{}, "", ctx.Err()
+	}
+	if p.createPanic != nil {
+		panic(p.createPanic)
+	}
+	return p.payload, "test-credentials-id", p.createErr
+}
+
+func (p *stubProvider) Delete(ctx context.Context, client SecretsManagerClient, config *Config) error {
+	p.deleteCalls++
+	LoggerFromContext(ctx).Info("deleting stub credentials")
+	if p.deletePanic != nil {
+		panic(p.deletePanic)
+	}
+	return p.deleteErr
+}
+
+// TestRunTask tests that RunTask dispatches the action to the provider and reports the result to Secrets Manager
+func TestRunTask(t *testing.T) {
+	validPayload := CredentialsPayload{
+		PRIVATE_KEY_BASE64: "private-key",
+		CERTIFICATE_BASE64: "certificate",
+	}
+
+	t.Run("Create credentials", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_COMMON_NAME: "test.example.com", SM_EXPIRATION_DAYS: 30}
+
+		err := RunTask(context.Background(), mockClient, &config, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, config.SM_CREDENTIALS_ID)
+		assert.Equal(t, config.SM_CREDENTIALS_ID, mockClient.NewCustomCredentialsNewCredentialsCalls[0].ID)
+	})
+
+	t.Run("Delete credentials", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_DeleteCredentials, SM_CREDENTIALS_ID: "test-credentials-id"}
+
+		err := RunTask(context.Background(), mockClient, &config, NewCertificateProvider())
+
+		assert.NoError(t, err)
+		mockClient.AssertCredentialsDeleted(t)
+	})
+
+	t.Run("Unknown action", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: "rotate_credentials"}
+
+		err := RunTask(context.Background(), mockClient, &config, &stubProvider{})
+
+		assert.ErrorContains(t, err, "unknown action: 'rotate_credentials'")
+		mockClient.AssertTaskFailed(t, ErrUnknownAction)
+	})
+
+	t.Run("Create error with code", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{createErr: TaskErrorf("Err10002", "cannot generate ECDSA private key")}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, "Err10002")
+		assert.Equal(t, 0, provider.deleteCalls)
+	})
+
+	t.Run("Create error without code", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+
+		err := RunTask(context.Background(), mockClient, &config, &stubProvider{createErr: errors.New("target system is unavailable")})
+
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, ErrCredentialsNotCreated)
+	})
+
+	t.Run("Credentials are deleted when the task cannot be updated", func(t *testing.T) {
+		setFastRetries(t)
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+			return nil, nil, errors.New("service unavailable")
+		}
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{payload: validPayload}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.ErrorContains(t, err, "credentials with credentials id: 'test-credentials-id' were deleted")
+		assert.Equal(t, 1, provider.deleteCalls)
+	})
+
+	t.Run("Delete error after the task cannot be updated", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{deleteErr: errors.New("target system is unavailable")}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.ErrorContains(t, err, "cannot delete credentials with credentials id: 'test-credentials-id'. error: target system is unavailable")
+		mockClient.AssertTaskFailed(t, ErrCredentialsPayloadInvalid)
+		assert.Equal(t, 1, provider.deleteCalls)
+	})
+
+	t.Run("Job deadline exceeded", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := RunTask(ctx, mockClient, &config, &stubProvider{blocking: true})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		mockClient.AssertTaskFailed(t, ErrJobTimeout)
+	})
+
+	t.Run("Job deadline exceeded with a coded error", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		provider := &stubProvider{blocking: true, createErr: NewCertificateNotCreatedError("1", context.DeadlineExceeded)}
+
+		err := RunTask(ctx, mockClient, &config, provider)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, []MockSecretTaskErrorCall{
+			{Code: ErrJobTimeout, Description: "cannot create credentials: cannot create certificate with serial number: '1'. error: context deadline exceeded. remediation: " + ErrorCatalog[ErrJobTimeout].Remediation},
+		}, mockClient.NewSecretTaskErrorCalls)
+	})
+
+	t.Run("Create panics", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{createPanic: "cannot login with password=hunter2"}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.EqualError(t, err, "unexpected error in the credentials provider: cannot login with password=[REDACTED]")
+		mockClient.AssertTaskFailed(t, ErrProviderPanic)
+		assert.Equal(t, 0, provider.deleteCalls)
+	})
+
+	t.Run("Credentials are deleted after a panic", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+			if len(mockClient.ReplaceSecretTaskCalls) == 1 {
+				panic("assignment to entry in nil map")
+			}
+			return &sm.SecretTask{}, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+		}
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{payload: validPayload}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.ErrorContains(t, err, "credentials with credentials id: 'test-credentials-id' were deleted")
+		mockClient.AssertTaskFailed(t, ErrProviderPanic)
+		assert.Equal(t, 1, provider.deleteCalls)
+	})
+
+	t.Run("Delete panics while credentials are deleted", func(t *testing.T) {
+		setFastRetries(t)
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = replaceSecretTaskResponses(nil, http.StatusServiceUnavailable)
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{payload: validPayload, deletePanic: "nil pointer dereference"}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.ErrorContains(t, err, "cannot delete credentials with credentials id: 'test-credentials-id'. error: unexpected error in the credentials provider: nil pointer dereference")
+	})
+
+	t.Run("Credentials are kept after a panic that follows the task update", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+			// A successful update without a task makes the log of the result panic
+			return nil, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+		}
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{payload: validPayload}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.NoError(t, err)
+		assert.Len(t, mockClient.ReplaceSecretTaskCalls, 1)
+		assert.Equal(t, 0, provider.deleteCalls)
+	})
+
+	t.Run("Panic and delete error are reported separately", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+			if len(mockClient.ReplaceSecretTaskCalls) == 1 {
+				panic("assignment to entry in nil map")
+			}
+			return &sm.SecretTask{}, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+		}
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials}
+		provider := &stubProvider{payload: validPayload, deleteErr: errors.New("target system is unavailable")}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.ErrorContains(t, err, "cannot delete credentials with credentials id: 'test-credentials-id'. error: target system is unavailable")
+		assert.Equal(t, []MockSecretTaskErrorCall{
+			{Code: ErrProviderPanic, Description: "unexpected error in the credentials provider: assignment to entry in nil map"},
+			{Code: ErrCredentialsNotDeleted, Description: "cannot delete credentials with credentials id: 'test-credentials-id'. error: target system is unavailable"},
+		}, mockClient.NewSecretTaskErrorCalls)
+	})
+
+	t.Run("Delete panics", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_DeleteCredentials, SM_CREDENTIALS_ID: "test-credentials-id"}
+		provider := &stubProvider{deletePanic: "nil pointer dereference"}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, ErrProviderPanic)
+		assert.Equal(t, 1, provider.deleteCalls)
+	})
+
+	t.Run("Delete error", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_DeleteCredentials, SM_CREDENTIALS_ID: "test-credentials-id"}
+
+		err := RunTask(context.Background(), mockClient, &config, &stubProvider{deleteErr: errors.New("target system is unavailable")})
+
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, ErrCredentialsNotDeleted)
+	})
+}
+
+// hookedProvider is a stubProvider with TaskHooks for some triggers
+type hookedProvider struct {
+	stubProvider
+	hooks map[Trigger]TaskHooks
+}
+
+func (p *hookedProvider) Hooks() map[Trigger]TaskHooks {
+	return p.hooks
+}
+
+// TestRunTaskTriggerHooks tests that RunTask calls the TaskHooks of the trigger of the task around the action
+func TestRunTaskTriggerHooks(t *testing.T) {
+	t.Run("Hooks of the trigger are called around the action", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_DeleteCredentials, SM_TRIGGER: TriggerSecretVersionExpiration, SM_CREDENTIALS_ID: "test-credentials-id"}
+		var calls []string
+		provider := &hookedProvider{}
+		provider.hooks = map[Trigger]TaskHooks{
+			TriggerSecretVersionExpiration: {
+				Before: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					assert.Equal(t, 0, provider.deleteCalls, "Before should be called before Delete")
+					calls = append(calls, "before")
+					return nil
+				},
+				After: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					assert.Len(t, mockClient.ReplaceSecretTaskCalls, 1, "After should be called after the task update")
+					calls = append(calls, "after")
+					return nil
+				},
+			},
+		}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"before", "after"}, calls)
+		mockClient.AssertCredentialsDeleted(t)
+	})
+
+	t.Run("Hooks of other triggers are not called", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_DeleteCredentials, SM_TRIGGER: TriggerSecretVersionDataDeletion, SM_CREDENTIALS_ID: "test-credentials-id"}
+		provider := &hookedProvider{hooks: map[Trigger]TaskHooks{
+			TriggerSecretVersionExpiration: {
+				Before: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					t.Error("hook of another trigger was called")
+					return nil
+				},
+			},
+		}}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, provider.deleteCalls)
+	})
+
+	t.Run("Before hook error fails the task", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_TRIGGER: TriggerAutomaticSecretRotation}
+		provider := &hookedProvider{hooks: map[Trigger]TaskHooks{
+			TriggerAutomaticSecretRotation: {
+				Before: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					return errors.New("previous credentials are still in use")
+				},
+			},
+		}}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.EqualError(t, err, "hook of trigger 'automatic_secret_rotation' failed: previous credentials are still in use")
+		mockClient.AssertTaskFailed(t, ErrTriggerHookFailed)
+		assert.Empty(t, mockClient.NewCustomCredentialsNewCredentialsCalls)
+	})
+
+	t.Run("Before hook error with code", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_TRIGGER: TriggerSecretCreation}
+		provider := &hookedProvider{hooks: map[Trigger]TaskHooks{
+			TriggerSecretCreation: {
+				Before: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					return TaskErrorf("Err10003", "quota exceeded")
+				},
+			},
+		}}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, "Err10003")
+	})
+
+	t.Run("After hook error does not fail the task", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_TRIGGER: TriggerManualSecretRotation}
+		provider := &hookedProvider{stubProvider: stubProvider{payload: CredentialsPayload{PRIVATE_KEY_BASE64: "private-key", CERTIFICATE_BASE64: "certificate"}}, hooks: map[Trigger]TaskHooks{
+			TriggerManualSecretRotation: {
+				After: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					return errors.New("cannot tag credentials")
+				},
+			},
+		}}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, provider.deleteCalls)
+	})
+
+	t.Run("After hook panics after a successful create", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_TRIGGER: TriggerManualSecretRotation}
+		provider := &hookedProvider{stubProvider: stubProvider{payload: CredentialsPayload{PRIVATE_KEY_BASE64: "private-key", CERTIFICATE_BASE64: "certificate"}}, hooks: map[Trigger]TaskHooks{
+			TriggerManualSecretRotation: {
+				After: func(ctx context.Context, client SecretsManagerClient, config *Config) error {
+					panic("assignment to entry in nil map")
+				},
+			},
+		}}
+
+		err := RunTask(context.Background(), mockClient, &config, provider)
+
+		assert.NoError(t, err)
+		assert.Len(t, mockClient.ReplaceSecretTaskCalls, 1)
+		assert.Empty(t, mockClient.NewSecretTaskErrorCalls)
+		assert.Equal(t, 0, provider.deleteCalls)
+	})
+}
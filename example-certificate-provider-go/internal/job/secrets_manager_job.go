@@ -3,14 +3,27 @@ package job
 // Auto-generated by secrets-manager-job-generator
 
 import (
+	"bufio"
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/IBM/go-sdk-core/v5/core"
 	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
@@ -29,16 +42,29 @@ type Config struct {
 	SM_SECRET_VERSION_ID string
 	SM_SECRET_ID         string
 	SM_ACTION            string
-	SM_TRIGGER           string
+	SM_TRIGGER           Trigger
+
+	// Job settings
+	SM_JOB_TIMEOUT          time.Duration // From env: SM_JOB_TIMEOUT, in seconds
+	SM_AUTH_TYPE            string        // From env: SM_AUTH_TYPE, the authentication method: iam, container or vpc
+	SM_TRUSTED_PROFILE_ID   string        // From env: SM_TRUSTED_PROFILE_ID
+	SM_TRUSTED_PROFILE_NAME string        // From env: SM_TRUSTED_PROFILE_NAME
+	SM_CR_TOKEN_FILENAME    string        // From env: SM_CR_TOKEN_FILENAME, the compute resource token file of the container
+	SM_IAM_URL              string        // From env: SM_IAM_URL, overrides the IAM URL derived from SM_INSTANCE_URL
+	SM_LOG_LEVEL            slog.Level    // From env: SM_LOG_LEVEL: debug, info, warn or error
+
+	// Limits of the task update retries, 0 for the limits of TaskUpdateRetryPolicy
+	SM_TASK_UPDATE_MAX_ATTEMPTS int           // From env: SM_TASK_UPDATE_MAX_ATTEMPTS
+	SM_TASK_UPDATE_MAX_BACKOFF  time.Duration // From env: SM_TASK_UPDATE_MAX_BACKOFF, in seconds
 
 	// User fields
-	SM_COMMON_NAME     string // From env: SMIN_COMMON_NAME
-	SM_ORG             string // From env: SMIN_ORG
-	SM_COUNTRY         string // From env: SMIN_COUNTRY
-	SM_SAN             string // From env: SMIN_SAN
-	SM_EXPIRATION_DAYS int    // From env: SMIN_EXPIRATION_DAYS
-	SM_KEY_ALGO        string // From env: SMIN_KEY_ALGO
-	SM_SIGN_ALGO       string // From env: SMIN_SIGN_ALGO
+	SM_COMMON_NAME     string   `validate:"max=64"` // From env: SMIN_COMMON_NAME
+	SM_ORG             string   // From env: SMIN_ORG
+	SM_COUNTRY         string   `validate:"omitempty,pattern"` // From env: SMIN_COUNTRY
+	SM_SAN             []string // From env: SMIN_SAN
+	SM_EXPIRATION_DAYS int      `validate:"omitempty,min=1,max=825"` // From env: SMIN_EXPIRATION_DAYS
+	SM_KEY_ALGO        KeyAlgo  // From env: SMIN_KEY_ALGO
+	SM_SIGN_ALGO       SignAlgo // From env: SMIN_SIGN_ALGO
 }
 
 // CredentialsPayload contains fields for SMOUT_ environment variables
@@ -47,47 +73,236 @@ type CredentialsPayload struct {
 	CERTIFICATE_BASE64 string `json:"certificate_base64" validate:"required,max=100000"`
 }
 
+// Trigger holds the allowed values of the SM_TRIGGER variable
+type Trigger string
+
+const (
+	TriggerSecretCreation            Trigger = "secret_creation"
+	TriggerManualSecretRotation      Trigger = "manual_secret_rotation"
+	TriggerAutomaticSecretRotation   Trigger = "automatic_secret_rotation"
+	TriggerSecretVersionExpiration   Trigger = "secret_version_expiration"
+	TriggerSecretVersionDataDeletion Trigger = "secret_version_data_deletion"
+)
+
+// ParseTrigger converts the given value to Trigger or returns an error if the value is not allowed
+func ParseTrigger(value string) (Trigger, error) {
+	parsed := Trigger(value)
+	if !parsed.IsValid() {
+		return "", fmt.Errorf("invalid value '%s' for SM_TRIGGER. allowed values are: secret_creation, manual_secret_rotation, automatic_secret_rotation, secret_version_expiration, secret_version_data_deletion", value)
+	}
+	return parsed, nil
+}
+
+// IsValid reports whether the value is one of the declared Trigger options
+func (v Trigger) IsValid() bool {
+	switch v {
+	case TriggerSecretCreation, TriggerManualSecretRotation, TriggerAutomaticSecretRotation, TriggerSecretVersionExpiration, TriggerSecretVersionDataDeletion:
+		return true
+	}
+	return false
+}
+
+// KeyAlgo holds the allowed values of the SMIN_KEY_ALGO variable
+type KeyAlgo string
+
+const (
+	KeyAlgoRSA   KeyAlgo = "RSA"
+	KeyAlgoECDSA KeyAlgo = "ECDSA"
+)
+
+// ParseKeyAlgo converts the given value to KeyAlgo or returns an error if the value is not allowed
+func ParseKeyAlgo(value string) (KeyAlgo, error) {
+	parsed := KeyAlgo(value)
+	if !parsed.IsValid() {
+		return "", fmt.Errorf("invalid value '%s' for SMIN_KEY_ALGO. allowed values are: RSA, ECDSA", value)
+	}
+	return parsed, nil
+}
+
+// IsValid reports whether the value is one of the declared KeyAlgo options
+func (v KeyAlgo) IsValid() bool {
+	switch v {
+	case KeyAlgoRSA, KeyAlgoECDSA:
+		return true
+	}
+	return false
+}
+
+// SignAlgo holds the allowed values of the SMIN_SIGN_ALGO variable
+type SignAlgo string
+
+const (
+	SignAlgoSHA256 SignAlgo = "SHA256"
+	SignAlgoSHA512 SignAlgo = "SHA512"
+)
+
+// ParseSignAlgo converts the given value to SignAlgo or returns an error if the value is not allowed
+func ParseSignAlgo(value string) (SignAlgo, error) {
+	parsed := SignAlgo(value)
+	if !parsed.IsValid() {
+		return "", fmt.Errorf("invalid value '%s' for SMIN_SIGN_ALGO. allowed values are: SHA256, SHA512", value)
+	}
+	return parsed, nil
+}
+
+// IsValid reports whether the value is one of the declared SignAlgo options
+func (v SignAlgo) IsValid() bool {
+	switch v {
+	case SignAlgoSHA256, SignAlgoSHA512:
+		return true
+	}
+	return false
+}
+
+// ErrorCategory tells what kind of problem an error code stands for
+type ErrorCategory string
+
+// Categories of the error codes
+const (
+	ErrorCategoryConfig   ErrorCategory = "config"   // An input variable or a selected secret must be corrected
+	ErrorCategoryAuth     ErrorCategory = "auth"     // The job cannot authenticate to a system or lacks permissions
+	ErrorCategoryUpstream ErrorCategory = "upstream" // A call to Secrets Manager or to the target system failed
+	ErrorCategoryInternal ErrorCategory = "internal" // The job or the credentials provider failed
+)
+
+// ErrorCodeInfo describes an error code of the error catalog
+type ErrorCodeInfo struct {
+	Category    ErrorCategory
+	Message     string // The user-facing message, with {parameter} placeholders for the values of the constructor
+	Remediation string // Tells the user how to correct the error. It is reported unless the error has its own.
+	Retryable   bool   // A later run of the task can succeed without changes, e.g. after an outage of the target system
+}
+
+// Error codes reported to Secrets Manager. The codes from ERR11000 are reported by the generated code, the
+// others are declared in error_codes.json.
+const (
+	ErrECDSAKeyNotGenerated       = "ERR10002" // cannot generate ECDSA private key: {error}
+	ErrRSAKeyNotGenerated         = "ERR10003" // cannot generate RSA private key: {error}
+	ErrCertificateNotCreated      = "ERR10004" // cannot create certificate with serial number: '{serialNumber}'. error: {error}
+	ErrCredentialsPayloadInvalid  = "ERR11004" // The created credentials do not match the output variables
+	ErrCredentialsPayloadTooLarge = "ERR11005" // The created credentials exceed the size limit of Secrets Manager
+	ErrInvalidJobConfig           = "ERR11006" // An input variable has an invalid value
+	ErrUnknownAction              = "ERR11007" // The action of the secret task is not supported
+	ErrCredentialsNotCreated      = "ERR11008" // The credentials cannot be created
+	ErrCredentialsNotDeleted      = "ERR11009" // The credentials cannot be deleted
+	ErrJobTimeout                 = "ERR11010" // The job did not complete before its deadline
+	ErrProviderPanic              = "ERR11011" // The credentials provider failed unexpectedly
+	ErrTriggerHookFailed          = "ERR11012" // The hook of the trigger of the secret task failed
+)
+
+// ErrorCatalog describes the error codes, keyed by code
+var ErrorCatalog = map[string]ErrorCodeInfo{
+	ErrECDSAKeyNotGenerated:       {Category: ErrorCategoryInternal, Message: "cannot generate ECDSA private key: {error}", Retryable: true},
+	ErrRSAKeyNotGenerated:         {Category: ErrorCategoryInternal, Message: "cannot generate RSA private key: {error}", Retryable: true},
+	ErrCertificateNotCreated:      {Category: ErrorCategoryConfig, Message: "cannot create certificate with serial number: '{serialNumber}'. error: {error}", Remediation: "check that SMIN_KEY_ALGO and SMIN_SIGN_ALGO are compatible and that SMIN_SAN contains valid DNS names"},
+	ErrCredentialsPayloadInvalid:  {Category: ErrorCategoryInternal, Message: "The created credentials do not match the output variables"},
+	ErrCredentialsPayloadTooLarge: {Category: ErrorCategoryInternal, Message: "The created credentials exceed the size limit of Secrets Manager"},
+	ErrInvalidJobConfig:           {Category: ErrorCategoryConfig, Message: "An input variable has an invalid value"},
+	ErrUnknownAction:              {Category: ErrorCategoryInternal, Message: "The action of the secret task is not supported"},
+	ErrCredentialsNotCreated:      {Category: ErrorCategoryUpstream, Message: "The credentials cannot be created", Retryable: true},
+	ErrCredentialsNotDeleted:      {Category: ErrorCategoryUpstream, Message: "The credentials cannot be deleted", Retryable: true},
+	ErrJobTimeout:                 {Category: ErrorCategoryInternal, Message: "The job did not complete before its deadline", Remediation: "increase the max execution time of the job and SM_JOB_TIMEOUT", Retryable: true},
+	ErrProviderPanic:              {Category: ErrorCategoryInternal, Message: "The credentials provider failed unexpectedly"},
+	ErrTriggerHookFailed:          {Category: ErrorCategoryInternal, Message: "The hook of the trigger of the secret task failed"},
+}
+
+// NewECDSAKeyNotGeneratedError returns an error with the ErrECDSAKeyNotGenerated code: cannot generate ECDSA private key: {error}
+// It returns nil if err is nil.
+func NewECDSAKeyNotGeneratedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Code: ErrECDSAKeyNotGenerated, Err: fmt.Errorf("cannot generate ECDSA private key: %w", err)}
+}
+
+// NewRSAKeyNotGeneratedError returns an error with the ErrRSAKeyNotGenerated code: cannot generate RSA private key: {error}
+// It returns nil if err is nil.
+func NewRSAKeyNotGeneratedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Code: ErrRSAKeyNotGenerated, Err: fmt.Errorf("cannot generate RSA private key: %w", err)}
+}
+
+// NewCertificateNotCreatedError returns an error with the ErrCertificateNotCreated code: cannot create certificate with serial number: '{serialNumber}'. error: {error}
+// It returns nil if err is nil.
+func NewCertificateNotCreatedError(serialNumber string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Code: ErrCertificateNotCreated, Err: fmt.Errorf("cannot create certificate with serial number: '%s'. error: %w", serialNumber, err)}
+}
+
+// IsRetryable reports whether the code of err stands for a problem that can disappear in a later run of the task
+func IsRetryable(err error) bool {
+	return ErrorCatalog[ErrorCode(err, "")].Retryable
+}
+
+// ConfigFieldError describes an environment variable that could not be loaded into the Config
+type ConfigFieldError struct {
+	Variable string // The variable name, e.g. SM_SECRET_TASK_ID or SMIN_EXPIRATION_DAYS
+	Value    string // The offending value, empty if the variable is not set
+	Message  string
+}
+
+func (e ConfigFieldError) Error() string {
+	return e.Message
+}
+
+// ConfigError holds all the errors found while loading the Config from environment variables
+type ConfigError struct {
+	Errors []ConfigFieldError
+}
+
+func (e *ConfigError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Message)
+	}
+	return fmt.Sprintf("configuration errors: %s", strings.Join(messages, "; "))
+}
+
+// add records an error for the given variable and value
+func (e *ConfigError) add(variable string, value string, message string) {
+	e.Errors = append(e.Errors, ConfigFieldError{Variable: variable, Value: value, Message: message})
+}
+
 // ConfigFromEnv creates a Config from environment variables and validates it
 func ConfigFromEnv() (Config, error) {
 	var config Config
-	var errs []string
+	var configErr ConfigError
 
 	// Declare common variables
 	var value string
-	var processedValue interface{}
 	var err error
 	// Process common variables
-	value, err = MustGetEnvVar("SM_ACCESS_APIKEY")
-	if err != nil {
-		errs = append(errs, err.Error())
-	} else {
-		config.SM_ACCESS_APIKEY = value
-	}
+	value = GetEnvVar("SM_ACCESS_APIKEY")
+	config.SM_ACCESS_APIKEY = value
 
 	value, err = MustGetEnvVar("SM_INSTANCE_URL")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_INSTANCE_URL", value, err.Error())
 	} else {
 		config.SM_INSTANCE_URL = value
 	}
 
 	value, err = MustGetEnvVar("SM_SECRET_GROUP_ID")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_SECRET_GROUP_ID", value, err.Error())
 	} else {
 		config.SM_SECRET_GROUP_ID = value
 	}
 
 	value, err = MustGetEnvVar("SM_SECRET_NAME")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_SECRET_NAME", value, err.Error())
 	} else {
 		config.SM_SECRET_NAME = value
 	}
 
 	value, err = MustGetEnvVar("SM_SECRET_TASK_ID")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_SECRET_TASK_ID", value, err.Error())
 	} else {
 		config.SM_SECRET_TASK_ID = value
 	}
@@ -100,23 +315,74 @@ func ConfigFromEnv() (Config, error) {
 
 	value, err = MustGetEnvVar("SM_SECRET_ID")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_SECRET_ID", value, err.Error())
 	} else {
 		config.SM_SECRET_ID = value
 	}
 
 	value, err = MustGetEnvVar("SM_ACTION")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_ACTION", value, err.Error())
 	} else {
 		config.SM_ACTION = value
 	}
 
 	value, err = MustGetEnvVar("SM_TRIGGER")
 	if err != nil {
-		errs = append(errs, err.Error())
+		configErr.add("SM_TRIGGER", value, err.Error())
 	} else {
-		config.SM_TRIGGER = value
+		config.SM_TRIGGER = Trigger(value)
+	}
+
+	// Process the job settings
+	value = GetEnvVar("SM_JOB_TIMEOUT")
+	if value == "" {
+		config.SM_JOB_TIMEOUT = DefaultJobTimeout
+	} else if timeout, err := parseJobTimeout(value); err != nil {
+		configErr.add("SM_JOB_TIMEOUT", value, err.Error())
+	} else {
+		config.SM_JOB_TIMEOUT = timeout
+	}
+	config.SM_TRUSTED_PROFILE_ID = GetEnvVar("SM_TRUSTED_PROFILE_ID")
+	config.SM_TRUSTED_PROFILE_NAME = GetEnvVar("SM_TRUSTED_PROFILE_NAME")
+	config.SM_CR_TOKEN_FILENAME = GetEnvVar("SM_CR_TOKEN_FILENAME")
+	value = GetEnvVar("SM_IAM_URL")
+	if value != "" {
+		if _, err := parseURL("SM_IAM_URL", value); err != nil {
+			configErr.add("SM_IAM_URL", value, err.Error())
+		} else {
+			config.SM_IAM_URL = value
+		}
+	}
+	value = GetEnvVar("SM_LOG_LEVEL")
+	if value == "" {
+		config.SM_LOG_LEVEL = slog.LevelInfo
+	} else if level, err := parseLogLevel(value); err != nil {
+		configErr.add("SM_LOG_LEVEL", value, err.Error())
+	} else {
+		config.SM_LOG_LEVEL = level
+	}
+	value = GetEnvVar("SM_TASK_UPDATE_MAX_ATTEMPTS")
+	if value != "" {
+		if attempts, err := parseMaxAttempts(value); err != nil {
+			configErr.add("SM_TASK_UPDATE_MAX_ATTEMPTS", value, err.Error())
+		} else {
+			config.SM_TASK_UPDATE_MAX_ATTEMPTS = attempts
+		}
+	}
+	value = GetEnvVar("SM_TASK_UPDATE_MAX_BACKOFF")
+	if value != "" {
+		if backoff, err := parseMaxBackoff(value); err != nil {
+			configErr.add("SM_TASK_UPDATE_MAX_BACKOFF", value, err.Error())
+		} else {
+			config.SM_TASK_UPDATE_MAX_BACKOFF = backoff
+		}
+	}
+	config.SM_AUTH_TYPE = GetEnvVar("SM_AUTH_TYPE")
+	if authType, err := selectAuthType(&config); err != nil {
+		configErr.add("SM_AUTH_TYPE", config.SM_AUTH_TYPE, err.Error())
+	} else {
+		config.SM_AUTH_TYPE = authType
 	}
 
 	// Process user variables
@@ -127,16 +393,14 @@ func ConfigFromEnv() (Config, error) {
 	if value == "" {
 		isRequired := true
 		if isRequired {
-			errs = append(errs, "required environment variable SM_COMMON_NAME_VALUE is not set")
+			configErr.add("SMIN_COMMON_NAME", value, "required environment variable SM_COMMON_NAME_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "string")
-		if err != nil {
-			errs = append(errs, err.Error())
-		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_COMMON_NAME").Set(reflect.ValueOf(processedValue))
+		config.SM_COMMON_NAME = value
+
+		// Check the declared constraints
+		if len([]rune(config.SM_COMMON_NAME)) > 64 {
+			configErr.add("SMIN_COMMON_NAME", value, fmt.Sprintf("invalid value '%s' for SMIN_COMMON_NAME. must be at most 64 characters long", value))
 		}
 	}
 
@@ -147,17 +411,10 @@ func ConfigFromEnv() (Config, error) {
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_ORG_VALUE is not set")
+			configErr.add("SMIN_ORG", value, "required environment variable SM_ORG_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "string")
-		if err != nil {
-			errs = append(errs, err.Error())
-		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_ORG").Set(reflect.ValueOf(processedValue))
-		}
+		config.SM_ORG = value
 	}
 
 	// Process SM_COUNTRY as string
@@ -167,110 +424,141 @@ func ConfigFromEnv() (Config, error) {
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_COUNTRY_VALUE is not set")
+			configErr.add("SMIN_COUNTRY", value, "required environment variable SM_COUNTRY_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "string")
-		if err != nil {
-			errs = append(errs, err.Error())
-		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_COUNTRY").Set(reflect.ValueOf(processedValue))
+		config.SM_COUNTRY = value
+
+		// Check the declared constraints
+		if !fieldPatterns["Config.SM_COUNTRY"].MatchString(config.SM_COUNTRY) {
+			configErr.add("SMIN_COUNTRY", value, fmt.Sprintf("invalid value '%s' for SMIN_COUNTRY. must match the pattern '^[A-Z]{2}$'", value))
 		}
 	}
 
-	// Process SM_SAN as string
+	// Process SM_SAN as string_list
 	value = GetEnvVar("SM_SAN_VALUE")
 
 	// Skip if value is empty and not explicitly required
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_SAN_VALUE is not set")
+			configErr.add("SMIN_SAN", value, "required environment variable SM_SAN_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "string")
-		if err != nil {
-			errs = append(errs, err.Error())
+		if parsedValue, err := parseStringList("SMIN_SAN", value); err != nil {
+			configErr.add("SMIN_SAN", value, err.Error())
 		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_SAN").Set(reflect.ValueOf(processedValue))
+			config.SM_SAN = parsedValue
 		}
 	}
 
 	// Process SM_EXPIRATION_DAYS as integer
 	value = GetEnvVar("SM_EXPIRATION_DAYS_VALUE")
 
+	// Use the declared default value if not set by the user
+	if value == "" {
+		value = "90"
+	}
+
 	// Skip if value is empty and not explicitly required
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_EXPIRATION_DAYS_VALUE is not set")
+			configErr.add("SMIN_EXPIRATION_DAYS", value, "required environment variable SM_EXPIRATION_DAYS_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "integer")
-		if err != nil {
-			errs = append(errs, err.Error())
+		if parsedValue, err := parseInteger("SMIN_EXPIRATION_DAYS", value); err != nil {
+			configErr.add("SMIN_EXPIRATION_DAYS", value, err.Error())
 		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_EXPIRATION_DAYS").Set(reflect.ValueOf(processedValue))
+			config.SM_EXPIRATION_DAYS = parsedValue
+
+			// Check the declared constraints
+			if config.SM_EXPIRATION_DAYS < 1 {
+				configErr.add("SMIN_EXPIRATION_DAYS", value, fmt.Sprintf("invalid value '%s' for SMIN_EXPIRATION_DAYS. must be at least 1", value))
+			}
+			if config.SM_EXPIRATION_DAYS > 825 {
+				configErr.add("SMIN_EXPIRATION_DAYS", value, fmt.Sprintf("invalid value '%s' for SMIN_EXPIRATION_DAYS. must be at most 825", value))
+			}
 		}
 	}
 
 	// Process SM_KEY_ALGO as enum[RSA|ECDSA]
 	value = GetEnvVar("SM_KEY_ALGO_VALUE")
 
+	// Use the declared default value if not set by the user
+	if value == "" {
+		value = "RSA"
+	}
+
 	// Skip if value is empty and not explicitly required
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_KEY_ALGO_VALUE is not set")
+			configErr.add("SMIN_KEY_ALGO", value, "required environment variable SM_KEY_ALGO_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "enum[RSA|ECDSA]")
-		if err != nil {
-			errs = append(errs, err.Error())
+		if parsedValue, err := ParseKeyAlgo(value); err != nil {
+			configErr.add("SMIN_KEY_ALGO", value, err.Error())
 		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_KEY_ALGO").Set(reflect.ValueOf(processedValue))
+			config.SM_KEY_ALGO = parsedValue
 		}
 	}
 
 	// Process SM_SIGN_ALGO as enum[SHA256|SHA512]
 	value = GetEnvVar("SM_SIGN_ALGO_VALUE")
 
+	// Use the declared default value if not set by the user
+	if value == "" {
+		value = "SHA256"
+	}
+
 	// Skip if value is empty and not explicitly required
 	if value == "" {
 		isRequired := false
 		if isRequired {
-			errs = append(errs, "required environment variable SM_SIGN_ALGO_VALUE is not set")
+			configErr.add("SMIN_SIGN_ALGO", value, "required environment variable SM_SIGN_ALGO_VALUE is not set")
 		}
 	} else {
-		// Process the value based on type
-		processedValue, err = processValue(value, "enum[SHA256|SHA512]")
-		if err != nil {
-			errs = append(errs, err.Error())
+		if parsedValue, err := ParseSignAlgo(value); err != nil {
+			configErr.add("SMIN_SIGN_ALGO", value, err.Error())
 		} else {
-			// Add to config
-			reflect.ValueOf(&config).Elem().FieldByName("SM_SIGN_ALGO").Set(reflect.ValueOf(processedValue))
+			config.SM_SIGN_ALGO = parsedValue
 		}
 	}
 
-	if len(errs) > 0 {
-		return config, fmt.Errorf("configuration errors: %s", strings.Join(errs, "; "))
+	if len(configErr.Errors) > 0 {
+		return config, &configErr
 	}
 
 	return config, nil
 }
 
+// fieldPatterns holds the patterns declared in the job configuration, keyed by struct and field name
+var fieldPatterns = map[string]*regexp.Regexp{
+	"Config.SM_COUNTRY": regexp.MustCompile("^[A-Z]{2}$"),
+}
+
+var validate = newValidator()
+
+// newValidator creates a validator that supports the 'pattern' tag for fields with a declared pattern
+// and the 'json' tag for JSON outputs
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("pattern", func(fl validator.FieldLevel) bool {
+		pattern, ok := fieldPatterns[fl.Parent().Type().Name()+"."+fl.StructFieldName()]
+		return !ok || pattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("json", func(fl validator.FieldLevel) bool {
+		return json.Valid(fl.Field().Bytes())
+	})
+	return v
+}
+
 // Create interfaces for secrets manager client APIs
 type SecretsManagerClient interface {
-	GetSecret(options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error)
-	ReplaceSecretTask(options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error)
+	GetSecretWithContext(ctx context.Context, options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error)
+	GetSecretTaskWithContext(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error)
+	ReplaceSecretTaskWithContext(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error)
 	NewSecretTaskError(code, description string) (*sm.SecretTaskError, error)
 	NewCustomCredentialsNewCredentials(id string, credentials map[string]interface{}) (*sm.CustomCredentialsNewCredentials, error)
 }
@@ -280,14 +568,16 @@ type SMClient struct {
 	client *sm.SecretsManagerV2
 }
 
-var validate = validator.New()
+func (s *SMClient) GetSecretWithContext(ctx context.Context, options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error) {
+	return s.client.GetSecretWithContext(ctx, options)
+}
 
-func (s *SMClient) GetSecret(options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error) {
-	return s.client.GetSecret(options)
+func (s *SMClient) GetSecretTaskWithContext(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	return s.client.GetSecretTaskWithContext(ctx, options)
 }
 
-func (s *SMClient) ReplaceSecretTask(options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
-	return s.client.ReplaceSecretTask(options)
+func (s *SMClient) ReplaceSecretTaskWithContext(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	return s.client.ReplaceSecretTaskWithContext(ctx, options)
 }
 
 func (s *SMClient) NewSecretTaskError(code, description string) (*sm.SecretTaskError, error) {
@@ -300,14 +590,14 @@ func (s *SMClient) NewCustomCredentialsNewCredentials(id string, credentials map
 
 // Function to create new client with configuration
 func NewSecretsManagerClient(config Config) (SecretsManagerClient, error) {
-	iamURL := getIAMURL(config.SM_INSTANCE_URL)
+	authenticator, err := newAuthenticator(config, IAMURL(&config), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the '%s' authenticator: %w", config.SM_AUTH_TYPE, err)
+	}
 
 	service, err := sm.NewSecretsManagerV2(&sm.SecretsManagerV2Options{
-		URL: config.SM_INSTANCE_URL,
-		Authenticator: &core.IamAuthenticator{
-			URL:    iamURL,
-			ApiKey: config.SM_ACCESS_APIKEY,
-		},
+		URL:           config.SM_INSTANCE_URL,
+		Authenticator: authenticator,
 	})
 
 	if err != nil {
@@ -317,17 +607,92 @@ func NewSecretsManagerClient(config Config) (SecretsManagerClient, error) {
 	return &SMClient{client: service}, nil
 }
 
-func getIAMURL(instanceURL string) string {
-	if strings.Contains(instanceURL, "secrets-manager.test.appdomain.cloud") {
-		return "https://iam.test.cloud.ibm.com"
+// newAuthenticator creates the authenticator of the method selected by config.SM_AUTH_TYPE. iamURL is the URL of
+// the IAM token service, vpcMetadataURL the URL of the VPC instance metadata service or empty for its default URL.
+func newAuthenticator(config Config, iamURL, vpcMetadataURL string) (core.Authenticator, error) {
+	switch config.SM_AUTH_TYPE {
+	case core.AUTHTYPE_CONTAINER:
+		// Exchange the compute resource token of the container for a Trusted Profile token
+		return core.NewContainerAuthenticatorBuilder().
+			SetIAMProfileID(config.SM_TRUSTED_PROFILE_ID).
+			SetIAMProfileName(config.SM_TRUSTED_PROFILE_NAME).
+			SetCRTokenFilename(config.SM_CR_TOKEN_FILENAME).
+			SetURL(iamURL).
+			Build()
+	case core.AUTHTYPE_VPC:
+		// Exchange the identity token of the VPC instance for a token of the Trusted Profile linked to it
+		return core.NewVpcInstanceAuthenticatorBuilder().
+			SetIAMProfileID(config.SM_TRUSTED_PROFILE_ID).
+			SetIAMProfileName(config.SM_TRUSTED_PROFILE_NAME).
+			SetURL(vpcMetadataURL).
+			Build()
+	default:
+		return core.NewIamAuthenticatorBuilder().
+			SetApiKey(config.SM_ACCESS_APIKEY).
+			SetURL(iamURL).
+			Build()
 	}
-	return "https://iam.cloud.ibm.com"
+}
+
+// selectAuthType returns the method the job authenticates with: the value of SM_AUTH_TYPE if set, a Trusted Profile
+// if only its variables are set, an API key otherwise. It checks that the variables of the method are set.
+func selectAuthType(config *Config) (string, error) {
+	authType := config.SM_AUTH_TYPE
+	hasTrustedProfile := config.SM_TRUSTED_PROFILE_ID != "" || config.SM_TRUSTED_PROFILE_NAME != ""
+	if authType == "" {
+		authType = core.AUTHTYPE_IAM
+		if config.SM_ACCESS_APIKEY == "" && hasTrustedProfile {
+			authType = core.AUTHTYPE_CONTAINER
+		}
+	}
+
+	switch authType {
+	case core.AUTHTYPE_IAM:
+		if config.SM_ACCESS_APIKEY == "" {
+			return authType, fmt.Errorf("environment variable SM_ACCESS_APIKEY is required but not set. set SM_TRUSTED_PROFILE_ID or SM_TRUSTED_PROFILE_NAME to authenticate with a trusted profile instead")
+		}
+	case core.AUTHTYPE_CONTAINER:
+		if !hasTrustedProfile {
+			return authType, fmt.Errorf("SM_TRUSTED_PROFILE_ID or SM_TRUSTED_PROFILE_NAME is required to authenticate with a trusted profile")
+		}
+	case core.AUTHTYPE_VPC:
+		// Without SM_TRUSTED_PROFILE_ID or SM_TRUSTED_PROFILE_NAME, the trusted profile linked to the instance is used
+	default:
+		return authType, fmt.Errorf("invalid value '%s' for SM_AUTH_TYPE. allowed values are: %s, %s, %s", authType, core.AUTHTYPE_IAM, core.AUTHTYPE_CONTAINER, core.AUTHTYPE_VPC)
+	}
+	if config.SM_TRUSTED_PROFILE_ID != "" && config.SM_TRUSTED_PROFILE_NAME != "" {
+		return authType, fmt.Errorf("SM_TRUSTED_PROFILE_ID and SM_TRUSTED_PROFILE_NAME cannot be both set")
+	}
+	return authType, nil
+}
+
+// IAMURL returns the URL of the IAM service: SM_IAM_URL if set, otherwise the IAM endpoint of the environment of the
+// Secrets Manager instance. A private instance URL, which is also used through a VPE, maps to the private IAM endpoint,
+// so that the job does not leave the private network.
+func IAMURL(config *Config) string {
+	if config.SM_IAM_URL != "" {
+		return strings.TrimSuffix(config.SM_IAM_URL, "/")
+	}
+
+	var host string
+	if instanceURL, err := url.Parse(config.SM_INSTANCE_URL); err == nil {
+		host = instanceURL.Hostname()
+	}
+	iamHost := "iam.cloud.ibm.com"
+	if strings.Contains(host, "secrets-manager.test.appdomain.cloud") {
+		iamHost = "iam.test.cloud.ibm.com"
+	}
+	// Private instance URLs have the form https://<instance_id>.private.<region>.secrets-manager.appdomain.cloud
+	if slices.Contains(strings.Split(host, "."), "private") {
+		iamHost = "private." + iamHost
+	}
+	return "https://" + iamHost
 }
 
 // GetSecret retrieves a secret from the IBM Cloud Secret Manager service.
-func GetSecret(client SecretsManagerClient, id string) (sm.SecretIntf, error) {
+func GetSecret(ctx context.Context, client SecretsManagerClient, id string) (sm.SecretIntf, error) {
 	options := &sm.GetSecretOptions{ID: core.StringPtr(id)}
-	res, resp, err := client.GetSecret(options)
+	res, resp, err := client.GetSecretWithContext(ctx, options)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get secret with ID '%s': %w", id, err)
 	}
@@ -351,25 +716,75 @@ func MustGetEnvVar(key string) (string, error) {
 	return value, nil
 }
 
-// Helper function to process values based on their type
-func processValue(value string, valueType string) (interface{}, error) {
-	switch valueType {
-	case "string":
-		return value, nil
-	case "integer":
-		return strconv.Atoi(value)
-	case "boolean":
-		return strconv.ParseBool(value)
-	default:
-		return value, nil // Default to string if type is unknown
+// parseInteger converts the value of an integer input variable
+func parseInteger(variable string, value string) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s' for %s. must be an integer", value, variable)
+	}
+	return parsed, nil
+}
+
+// parseBoolean converts the value of a boolean input variable
+func parseBoolean(variable string, value string) (bool, error) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value '%s' for %s. must be true or false", value, variable)
+	}
+	return parsed, nil
+}
+
+// parseStringList converts the value of a string_list input variable, a comma-separated list of items
+func parseStringList(variable string, value string) ([]string, error) {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+		if items[i] == "" {
+			return nil, fmt.Errorf("invalid value '%s' for %s. must be a comma-separated list without empty items", value, variable)
+		}
+	}
+	return items, nil
+}
+
+// parseJSON checks the value of a json input variable
+func parseJSON(variable string, value string) (json.RawMessage, error) {
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("invalid value '%s' for %s. must be a valid JSON document", value, variable)
+	}
+	return json.RawMessage(value), nil
+}
+
+// parseDuration converts the value of a duration input variable, e.g. 90s or 1h30m
+func parseDuration(variable string, value string) (time.Duration, error) {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s' for %s. must be a duration, e.g. 90s or 1h30m", value, variable)
 	}
+	return parsed, nil
 }
 
+// parseURL converts the value of a url input variable, which must be an absolute URL
+func parseURL(variable string, value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid value '%s' for %s. must be an absolute URL, e.g. https://example.com", value, variable)
+	}
+	return parsed, nil
+}
+
+// MaxCredentialsPayloadSize is the maximum size, in bytes, of the serialized credentials accepted by Secrets Manager
+const MaxCredentialsPayloadSize = 100000
+
 // UpdateTaskAboutCredentialsCreated updates a task status to succeeded and adds credentials to it.
-func UpdateTaskAboutCredentialsCreated(client SecretsManagerClient, config *Config, credentialsPayload CredentialsPayload) (*sm.SecretTask, error) {
+// A payload that Secrets Manager would reject is reported as a task error with ErrCredentialsPayloadInvalid or
+// ErrCredentialsPayloadTooLarge instead.
+func UpdateTaskAboutCredentialsCreated(ctx context.Context, client SecretsManagerClient, config *Config, credentialsPayload CredentialsPayload) (*sm.SecretTask, error) {
 	credentialsPayloadMap, err := ValidatedStructToMap(credentialsPayload)
 	if err != nil {
-		return nil, fmt.Errorf("cannot convert credentials payload to map: %w", err)
+		return nil, reportInvalidPayload(ctx, client, config, ErrCredentialsPayloadInvalid, fmt.Errorf("cannot convert credentials payload to map: %w", err))
+	}
+	if err := checkPayloadSize(credentialsPayloadMap); err != nil {
+		return nil, reportInvalidPayload(ctx, client, config, ErrCredentialsPayloadTooLarge, err)
 	}
 
 	customCredentials, err := client.NewCustomCredentialsNewCredentials(config.SM_CREDENTIALS_ID, credentialsPayloadMap)
@@ -382,57 +797,290 @@ func UpdateTaskAboutCredentialsCreated(client SecretsManagerClient, config *Conf
 		Credentials: customCredentials,
 	}
 
-	return UpdateTask(client, config, secretTaskPrototype)
+	return UpdateTask(ctx, client, config, secretTaskPrototype)
+}
+
+// checkPayloadSize checks the serialized size of the credentials against MaxCredentialsPayloadSize
+func checkPayloadSize(credentials map[string]interface{}) error {
+	serialized, err := json.Marshal(credentials)
+	if err != nil {
+		return fmt.Errorf("cannot serialize credentials payload: %w", err)
+	}
+	if len(serialized) > MaxCredentialsPayloadSize {
+		return fmt.Errorf("credentials payload is %d bytes, which exceeds the limit of %d bytes", len(serialized), MaxCredentialsPayloadSize)
+	}
+	return nil
+}
+
+// reportInvalidPayload reports a credentials payload that cannot be sent to Secrets Manager as a task error and returns err
+func reportInvalidPayload(ctx context.Context, client SecretsManagerClient, config *Config, code string, err error) error {
+	if _, taskErr := UpdateTaskAboutError(ctx, client, config, code, err.Error()); taskErr != nil {
+		return fmt.Errorf("%w. cannot update task about error: %v", err, taskErr)
+	}
+	return err
 }
 
 // UpdateTaskAboutCredentialsDeleted updates a task status to succeeded when credentials are deleted.
-func UpdateTaskAboutCredentialsDeleted(client SecretsManagerClient, config *Config) (result *sm.SecretTask, err error) {
+func UpdateTaskAboutCredentialsDeleted(ctx context.Context, client SecretsManagerClient, config *Config) (result *sm.SecretTask, err error) {
 	secretTaskPrototype := &sm.SecretTaskPrototypeUpdateSecretTaskCredentialsDeleted{
 		Status: core.StringPtr(sm.SecretTask_Status_CredentialsDeleted),
 	}
-	return UpdateTask(client, config, secretTaskPrototype)
+	return UpdateTask(ctx, client, config, secretTaskPrototype)
 }
 
+// Limits of the errors that UpdateTaskAboutErrors reports, which keep the task update within the limits of the
+// Secrets Manager API. The errors beyond MaxTaskErrors are summarized in the last reported error and the longer
+// descriptions are truncated. The job logs always contain the complete errors.
+const (
+	MaxTaskErrors                 = 10
+	MaxTaskErrorDescriptionLength = 1024
+)
+
 // UpdateTaskAboutError updates a task with the given code and description as errors.
-func UpdateTaskAboutError(client SecretsManagerClient, config *Config, code, description string) (result *sm.SecretTask, err error) {
+func UpdateTaskAboutError(ctx context.Context, client SecretsManagerClient, config *Config, code, description string) (result *sm.SecretTask, err error) {
+	var errs TaskErrors
+	errs.Add(errors.New(description))
+	return UpdateTaskAboutErrors(ctx, client, config, &errs, code)
+}
 
-	secretTaskError, err := client.NewSecretTaskError(code, description)
-	if err != nil {
-		return nil, fmt.Errorf("cannot construct a new secret task error resource: %w", err)
+// UpdateTaskAboutErrors updates a task status to failed with each of the accumulated errors, followed by its
+// remediation or the remediation of its code in ErrorCatalog. The errors without a code are reported with fallback.
+func UpdateTaskAboutErrors(ctx context.Context, client SecretsManagerClient, config *Config, errs *TaskErrors, fallback string) (result *sm.SecretTask, err error) {
+	var secretTaskErrors []sm.SecretTaskError
+	for _, taskErr := range limitTaskErrors(errs.errs) {
+		code := taskErr.Code
+		if code == "" {
+			code = fallback
+		}
+		secretTaskError, err := client.NewSecretTaskError(code, taskErrorDescription(taskErr, code))
+		if err != nil {
+			return nil, fmt.Errorf("cannot construct a new secret task error resource: %w", err)
+		}
+		secretTaskErrors = append(secretTaskErrors, *secretTaskError)
 	}
 
 	secretTaskPrototype := &sm.SecretTaskPrototypeUpdateSecretTaskFailed{
 		Status: core.StringPtr(sm.SecretTask_Status_Failed),
-		Errors: []sm.SecretTaskError{*secretTaskError},
+		Errors: secretTaskErrors,
 	}
 
-	return UpdateTask(client, config, secretTaskPrototype)
+	return UpdateTask(ctx, client, config, secretTaskPrototype)
 }
 
-// UpdateTask updates a secret task.
-func UpdateTask(client SecretsManagerClient, config *Config, secretTaskPrototypeIntf sm.SecretTaskPrototypeIntf) (*sm.SecretTask, error) {
+// limitTaskErrors returns at most MaxTaskErrors errors. The last one tells how many more errors are in the job logs.
+func limitTaskErrors(errs []*TaskError) []*TaskError {
+	if len(errs) <= MaxTaskErrors {
+		return errs
+	}
+	omitted := errs[MaxTaskErrors-1:]
+	return append(errs[:MaxTaskErrors-1:MaxTaskErrors-1], &TaskError{
+		Code: omitted[0].Code,
+		Err:  fmt.Errorf("%d more errors, see the logs of the job run. the first one is: %w", len(omitted), omitted[0].Err),
+	})
+}
+
+// taskErrorDescription returns the description of a task error reported with the given code and its remediation,
+// truncated to MaxTaskErrorDescriptionLength characters
+func taskErrorDescription(taskErr *TaskError, code string) string {
+	description := taskErr.Error()
+	remediation := taskErr.Remediation
+	if remediation == "" {
+		remediation = ErrorCatalog[code].Remediation
+	}
+	if remediation != "" {
+		description = fmt.Sprintf("%s. remediation: %s", description, remediation)
+	}
+	const ellipsis = "..."
+	if runes := []rune(description); len(runes) > MaxTaskErrorDescriptionLength {
+		description = string(runes[:MaxTaskErrorDescriptionLength-len(ellipsis)]) + ellipsis
+	}
+	return description
+}
+
+// RetryPolicy controls how UpdateTask retries the calls that fail with a network error, an HTTP 429 or an HTTP 5xx
+// response. The wait before a retry is doubled for every retry, with a random jitter, unless the response has a
+// Retry-After header.
+type RetryPolicy struct {
+	MaxAttempts    int           // The maximum number of calls, including the first one
+	InitialBackoff time.Duration // The wait before the first retry
+	MaxBackoff     time.Duration // The maximum wait before a retry, unless the response has a Retry-After header
+}
+
+// TaskUpdateRetryPolicy is the default RetryPolicy of UpdateTask. SM_TASK_UPDATE_MAX_ATTEMPTS and
+// SM_TASK_UPDATE_MAX_BACKOFF override its limits for a job run. The retries also end when the context is done.
+var TaskUpdateRetryPolicy = RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+// taskUpdateRetryPolicy returns TaskUpdateRetryPolicy with the limits of config, if set
+func taskUpdateRetryPolicy(config *Config) RetryPolicy {
+	policy := TaskUpdateRetryPolicy
+	if config.SM_TASK_UPDATE_MAX_ATTEMPTS > 0 {
+		policy.MaxAttempts = config.SM_TASK_UPDATE_MAX_ATTEMPTS
+	}
+	if config.SM_TASK_UPDATE_MAX_BACKOFF > 0 {
+		policy.MaxBackoff = config.SM_TASK_UPDATE_MAX_BACKOFF
+		policy.InitialBackoff = min(policy.InitialBackoff, policy.MaxBackoff)
+	}
+	return policy
+}
+
+// parseMaxAttempts converts the value of SM_TASK_UPDATE_MAX_ATTEMPTS, the maximum number of calls of a task update
+func parseMaxAttempts(value string) (int, error) {
+	attempts, err := strconv.Atoi(value)
+	if err != nil || attempts < 1 {
+		return 0, fmt.Errorf("invalid value '%s' for SM_TASK_UPDATE_MAX_ATTEMPTS. must be a positive integer", value)
+	}
+	return attempts, nil
+}
+
+// parseMaxBackoff converts the value of SM_TASK_UPDATE_MAX_BACKOFF, the maximum wait before a retry in seconds
+func parseMaxBackoff(value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 1 {
+		return 0, fmt.Errorf("invalid value '%s' for SM_TASK_UPDATE_MAX_BACKOFF. must be a positive number of seconds", value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// UpdateTask updates a secret task, retrying transient failures according to the retry policy of config, see
+// taskUpdateRetryPolicy. When a retried update is rejected because the task is already in a final state, the task
+// is read again. If it has the status of the update, the update was applied by an earlier call whose response was
+// lost, and is not treated as a failure. Otherwise, the task was completed by another update.
+func UpdateTask(ctx context.Context, client SecretsManagerClient, config *Config, secretTaskPrototypeIntf sm.SecretTaskPrototypeIntf) (*sm.SecretTask, error) {
 	options := &sm.ReplaceSecretTaskOptions{
 		SecretID: &config.SM_SECRET_ID,
 		ID:       &config.SM_SECRET_TASK_ID,
 		TaskPut:  secretTaskPrototypeIntf,
 	}
 
-	result, response, err := client.ReplaceSecretTask(options)
+	policy := taskUpdateRetryPolicy(config)
+	for attempt := 1; ; attempt++ {
+		LoggerFromContext(ctx).Debug("updating secret task", "attempt", attempt)
+		result, response, err := client.ReplaceSecretTaskWithContext(ctx, options)
+		if err == nil && response != nil && response.StatusCode == http.StatusOK {
+			return result, nil
+		}
+		if attempt > 1 && isTaskInFinalState(response, err) {
+			task, confirmErr := confirmTaskUpdate(ctx, client, options)
+			if confirmErr != nil {
+				return nil, fmt.Errorf("%w. %w", taskUpdateError(config, response, err), confirmErr)
+			}
+			LoggerFromContext(ctx).Info("secret task is already in a final state, the update was applied by an earlier attempt", "attempt", attempt)
+			return task, nil
+		}
+
+		err = taskUpdateError(config, response, err)
+		if attempt >= policy.MaxAttempts || !isRetryableTaskUpdate(ctx, response) {
+			return nil, err
+		}
+		wait := policy.backoff(attempt)
+		if retryAfter, ok := retryAfter(response); ok {
+			wait = retryAfter
+		}
+		LoggerFromContext(ctx).Warn("cannot update task, retrying", "attempt", attempt, "max_attempts", policy.MaxAttempts, "wait", wait.Round(time.Millisecond).String(), "error", err)
+		if waitErr := sleepContext(ctx, wait); waitErr != nil {
+			return nil, fmt.Errorf("%w. no more retries: %w", err, waitErr)
+		}
+	}
+}
+
+// taskUpdateError describes a failed ReplaceSecretTask call
+func taskUpdateError(config *Config, response *core.DetailedResponse, err error) error {
 	if err != nil {
-		return nil, fmt.Errorf("cannot update secret with ID: '%s' task with ID: '%s'. error: %w",
+		return fmt.Errorf("cannot update secret with ID: '%s' task with ID: '%s'. error: %w",
 			config.SM_SECRET_ID, config.SM_SECRET_TASK_ID, err)
 	}
-
 	if response == nil {
-		return nil, fmt.Errorf("cannot update secret task, no response")
+		return fmt.Errorf("cannot update secret task, no response")
 	}
+	return fmt.Errorf("cannot update secret with ID: '%s' task with ID: '%s'. status code is: '%d', response is %s",
+		config.SM_SECRET_ID, config.SM_SECRET_TASK_ID, response.StatusCode, response.String())
+}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("cannot update secret with ID: '%s' task with ID: '%s'. status code is: '%d', response is %s",
-			config.SM_SECRET_ID, config.SM_SECRET_TASK_ID, response.StatusCode, response.String())
+// isRetryableTaskUpdate reports whether a failed ReplaceSecretTask call can succeed when it is repeated
+func isRetryableTaskUpdate(ctx context.Context, response *core.DetailedResponse) bool {
+	if ctx.Err() != nil {
+		return false
 	}
+	// A call without response failed in transit
+	return response == nil || response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError
+}
 
-	return result, nil
+// isTaskInFinalState reports whether Secrets Manager rejected a task update because the task is already completed
+func isTaskInFinalState(response *core.DetailedResponse, err error) bool {
+	if response != nil && response.StatusCode == http.StatusConflict {
+		return true
+	}
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "final state")
+}
+
+// confirmTaskUpdate reads a task that is already in a final state and returns it if it has the status of the update
+func confirmTaskUpdate(ctx context.Context, client SecretsManagerClient, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, error) {
+	task, response, err := client.GetSecretTaskWithContext(ctx, &sm.GetSecretTaskOptions{SecretID: options.SecretID, ID: options.ID})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the secret task in a final state: %w", err)
+	}
+	if response == nil || response.StatusCode != http.StatusOK || task == nil {
+		return nil, fmt.Errorf("cannot read the secret task in a final state, unexpected response")
+	}
+	status := taskPutStatus(options.TaskPut)
+	if core.StringNilMapper(task.Status) != status {
+		return nil, fmt.Errorf("the secret task was completed with status '%s' instead of '%s' by another update", core.StringNilMapper(task.Status), status)
+	}
+	return task, nil
+}
+
+// taskPutStatus returns the status that a task update sets
+func taskPutStatus(taskPut sm.SecretTaskPrototypeIntf) string {
+	switch v := taskPut.(type) {
+	case *sm.SecretTaskPrototypeUpdateSecretTaskCredentialsCreated:
+		return core.StringNilMapper(v.Status)
+	case *sm.SecretTaskPrototypeUpdateSecretTaskCredentialsDeleted:
+		return core.StringNilMapper(v.Status)
+	case *sm.SecretTaskPrototypeUpdateSecretTaskFailed:
+		return core.StringNilMapper(v.Status)
+	case *sm.SecretTaskPrototype:
+		return core.StringNilMapper(v.Status)
+	}
+	return ""
+}
+
+// backoff returns the wait before the given retry: the exponential backoff with a random jitter of up to half of it
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.MaxBackoff
+	if retry < 32 && p.InitialBackoff<<(retry-1) < p.MaxBackoff {
+		backoff = p.InitialBackoff << (retry - 1)
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// retryAfter returns the wait requested by the Retry-After header of the response, in seconds or as an HTTP date
+func retryAfter(response *core.DetailedResponse) (time.Duration, bool) {
+	if response == nil || response.Headers == nil {
+		return 0, false
+	}
+	value := response.Headers.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for the given duration, or returns the error of the context if it is done first
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // ValidatedStructToMap converts a struct to a map[string]interface{} while performing validation
@@ -489,3 +1137,765 @@ func GetValueByPath(data map[string]interface{}, path string) (interface{}, bool
 	}
 	return current, true
 }
+
+// logLevels maps the values of SM_LOG_LEVEL to the levels of the logger
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// parseLogLevel converts the value of SM_LOG_LEVEL
+func parseLogLevel(value string) (slog.Level, error) {
+	level, ok := logLevels[strings.ToLower(value)]
+	if !ok {
+		return slog.LevelInfo, fmt.Errorf("invalid value '%s' for SM_LOG_LEVEL. allowed values are: debug, info, warn, error", value)
+	}
+	return level, nil
+}
+
+// logger is the logger of the job run. Run replaces it with a logger that carries the identifiers of the secret task.
+var logger = slog.New(NewLogHandler(os.Stderr, slog.LevelInfo))
+
+// loggerContextKey is the key of the logger that a context carries, see LoggerFromContext
+type loggerContextKey struct{}
+
+// contextWithLogger returns a copy of ctx that carries l
+func contextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// LoggerFromContext returns the logger that ctx carries, or logger if it carries none. Once the credentials are
+// created, RunTask passes a logger with their ID down in the context, e.g. to Delete when they are deleted again.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return logger
+}
+
+// NewLogHandler returns a handler that writes JSON log lines of at least the given level to w.
+// The values that look like credentials are redacted, see Redact.
+func NewLogHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr})
+}
+
+// NewLogger returns a logger whose lines carry the name of the provider and the identifiers of the secret task that are set
+func NewLogger(handler slog.Handler, config *Config, providerName string) *slog.Logger {
+	fields := []struct{ key, value string }{
+		{"provider", providerName},
+		{"secret_id", config.SM_SECRET_ID},
+		{"secret_name", config.SM_SECRET_NAME},
+		{"secret_version_id", config.SM_SECRET_VERSION_ID},
+		{"secret_task_id", config.SM_SECRET_TASK_ID},
+		{"action", config.SM_ACTION},
+		{"trigger", string(config.SM_TRIGGER)},
+		{"credentials_id", config.SM_CREDENTIALS_ID},
+	}
+	var attrs []any
+	for _, field := range fields {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+	return slog.New(handler).With(attrs...)
+}
+
+// redactedValue replaces the redacted parts of the log lines
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeyPattern matches the names of the log attributes whose values are always redacted, e.g. apikey or client_secret
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(^|_)(apikey|api_key|token|password|passwd|secret|private_key)$`)
+
+// redactionRules replace the parts of the log lines that look like credentials
+var redactionRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// PEM blocks, e.g. private keys and certificates
+	{regexp.MustCompile(`-----BEGIN [A-Z0-9 ]+-----[\s\S]*?-----END [A-Z0-9 ]+-----`), redactedValue},
+	// Authorization header values
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`), "$1 " + redactedValue},
+	// JSON web tokens
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), redactedValue},
+	// Values of key-value pairs whose key names a credential, e.g. apikey=... or "password": "..."
+	{regexp.MustCompile(`(?i)((?:api_?key|token|password|passwd|pwd|client_?secret)["']?\s*[:=]\s*["']?)[^\s"',;&}]+`), "${1}" + redactedValue},
+	// Long opaque strings, e.g. IBM Cloud API keys and JFrog reference tokens
+	{regexp.MustCompile(`\b[A-Za-z0-9_+=-]{44,}`), redactedValue},
+}
+
+// Redact replaces the parts of s that look like API keys, tokens, passwords or PEM blocks
+func Redact(s string) string {
+	for _, rule := range redactionRules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// redactAttr redacts the values of sensitive attributes and of the attributes in sensitive groups, and the parts of
+// the other values that look like credentials
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	for _, group := range groups {
+		if sensitiveKeyPattern.MatchString(group) {
+			return slog.String(attr.Key, redactedValue)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: redactValue(attr.Key, attr.Value)}
+}
+
+// redactValue redacts the value of an attribute with the given key. Groups are redacted attribute by attribute, and
+// errors by their message. The other values, e.g. structs, maps and slices, are redacted in their JSON form.
+func redactValue(key string, value slog.Value) slog.Value {
+	if sensitiveKeyPattern.MatchString(key) {
+		return slog.StringValue(redactedValue)
+	}
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.StringValue(Redact(value.String()))
+	case slog.KindGroup:
+		var attrs []slog.Attr
+		for _, attr := range value.Group() {
+			attrs = append(attrs, slog.Attr{Key: attr.Key, Value: redactValue(attr.Key, attr.Value.Resolve())})
+		}
+		return slog.GroupValue(attrs...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.StringValue(Redact(err.Error()))
+		}
+		return slog.AnyValue(redactJSON(value.Any()))
+	}
+	return value
+}
+
+// redactJSON returns the JSON form of v, decoded, with the values of sensitive keys redacted and the other strings
+// passed through Redact. A value that cannot be encoded as JSON is redacted in its fmt form.
+func redactJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Redact(fmt.Sprintf("%+v", v))
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return Redact(string(data))
+	}
+	return redactDecodedJSON(decoded)
+}
+
+// redactDecodedJSON redacts a value decoded from JSON in place
+func redactDecodedJSON(v any) any {
+	switch v := v.(type) {
+	case string:
+		return Redact(v)
+	case map[string]any:
+		for key, value := range v {
+			if sensitiveKeyPattern.MatchString(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactDecodedJSON(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactDecodedJSON(value)
+		}
+	}
+	return v
+}
+
+// DefaultJobTimeout is the job timeout when SM_JOB_TIMEOUT is not set, the default max execution time of Code Engine jobs
+const DefaultJobTimeout = 7200 * time.Second
+
+// TaskReportTimeout is the part of the job timeout that is reserved to report the result of the task to Secrets
+// Manager, and to delete the created credentials if the task cannot be updated
+const TaskReportTimeout = 30 * time.Second
+
+// CredentialsProvider implements the business logic of a job: creating and deleting credentials in the target system.
+// Run owns everything else: reading the configuration, dispatching the action of the secret task, reporting the
+// result to Secrets Manager and the exit code of the job.
+type CredentialsProvider interface {
+	// Create creates new credentials and returns them with the ID that identifies them in the target system.
+	// Run sets config.SM_CREDENTIALS_ID to the returned ID before it updates the task.
+	Create(ctx context.Context, client SecretsManagerClient, config *Config) (CredentialsPayload, string, error)
+	// Delete deletes the credentials identified by config.SM_CREDENTIALS_ID from the target system.
+	// Run also calls Delete to undo Create when Secrets Manager cannot be told about the created credentials.
+	Delete(ctx context.Context, client SecretsManagerClient, config *Config) error
+}
+
+// TaskHook is a function that RunTask calls for the secret tasks with a given trigger
+type TaskHook func(ctx context.Context, client SecretsManagerClient, config *Config) error
+
+// TaskHooks are the optional functions that RunTask calls around the action of a secret task
+type TaskHooks struct {
+	// Before is called before Create or Delete. An error fails the task without calling them.
+	Before TaskHook
+	// After is called after the task was updated with the result of Create or Delete. An error is only logged,
+	// because the task is already completed.
+	After TaskHook
+}
+
+// TriggerHooks is an optional interface of a CredentialsProvider that changes how the secret tasks are run
+// depending on what triggered them, e.g. to skip a verification when credentials are deleted because the secret
+// version expired
+type TriggerHooks interface {
+	// Hooks returns the TaskHooks of each trigger. The tasks with other triggers are run without hooks.
+	Hooks() map[Trigger]TaskHooks
+}
+
+// TaskError is an error with the code that is reported to Secrets Manager when it fails the secret task
+type TaskError struct {
+	Code string
+	Err  error
+	// Remediation optionally tells the user how to correct the error. It is reported after the description.
+	Remediation string
+}
+
+func (e *TaskError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// NewTaskError returns err with the code that is reported to Secrets Manager, or nil if err is nil
+func NewTaskError(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Code: code, Err: err}
+}
+
+// TaskErrorf formats an error with the code that is reported to Secrets Manager
+func TaskErrorf(code, format string, args ...interface{}) error {
+	return &TaskError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// WithRemediation returns err with a hint that tells the user how to correct it, or nil if err is nil.
+// The code of err is kept.
+func WithRemediation(err error, remediation string) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Code: ErrorCode(err, ""), Err: err, Remediation: remediation}
+}
+
+// TaskErrors accumulates the errors of a job run so that they are reported to Secrets Manager together instead of
+// in one concatenated description. The zero value is an empty accumulator.
+type TaskErrors struct {
+	errs []*TaskError
+}
+
+// Add adds err to the accumulated errors, with its code and remediation if it carries them. The errors of another
+// TaskErrors are added one by one. A nil err is ignored.
+func (e *TaskErrors) Add(err error) {
+	if err == nil {
+		return
+	}
+	if other, ok := err.(*TaskErrors); ok {
+		e.errs = append(e.errs, other.errs...)
+		return
+	}
+	taskErr := &TaskError{Code: ErrorCode(err, ""), Err: err}
+	for wrapped := err; wrapped != nil && taskErr.Remediation == ""; wrapped = errors.Unwrap(wrapped) {
+		if remediationErr, ok := wrapped.(*TaskError); ok {
+			taskErr.Remediation = remediationErr.Remediation
+		}
+	}
+	e.errs = append(e.errs, taskErr)
+}
+
+// Len returns the number of accumulated errors
+func (e *TaskErrors) Len() int {
+	return len(e.errs)
+}
+
+// Err returns the accumulated errors as an error, or nil if there are none
+func (e *TaskErrors) Err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *TaskErrors) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *TaskErrors) Unwrap() []error {
+	errs := make([]error, len(e.errs))
+	for i, err := range e.errs {
+		errs[i] = err.Err
+	}
+	return errs
+}
+
+// ErrorCode returns the code that is reported to Secrets Manager for err, or fallback if err does not carry one.
+func ErrorCode(err error, fallback string) string {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) && taskErr.Code != "" {
+		return taskErr.Code
+	}
+	return fallback
+}
+
+// Run is the entry point of the job. It reads the configuration from the environment, performs the action of
+// the secret task with provider and exits with code 1 if the task could not be completed.
+// If SM_DRY_RUN is true, it runs the job offline with RunDryRun and writes the result to the standard output.
+func Run(provider CredentialsProvider) {
+	if isDryRun() {
+		if err := RunDryRun(os.Stdout, provider); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	config, configErr := ConfigFromEnv()
+	logger = NewLogger(NewLogHandler(os.Stderr, config.SM_LOG_LEVEL), &config, providerName(provider))
+
+	client, err := NewSecretsManagerClient(config)
+	if err != nil {
+		if configErr != nil {
+			logger.Error("invalid job configuration", "error", configErr)
+		} else {
+			logger.Error("cannot create Secrets Manager client", "error", err)
+		}
+		os.Exit(1)
+	}
+
+	// Report invalid input values back to Secrets Manager so that the user can correct them
+	if configErr != nil {
+		reportTaskError(context.Background(), client, &config, ErrInvalidJobConfig, fmt.Errorf("invalid job configuration: %w", configErr))
+		os.Exit(1)
+	}
+
+	// Stop the work of the provider early enough to report the result before Code Engine stops the job
+	ctx, cancel := context.WithTimeout(context.Background(), config.SM_JOB_TIMEOUT-TaskReportTimeout)
+	err = RunTask(ctx, client, &config, provider)
+	cancel()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// providerName returns the name of the type of provider, e.g. CertificateProvider
+func providerName(provider CredentialsProvider) string {
+	providerType := reflect.TypeOf(provider)
+	if providerType.Kind() == reflect.Pointer {
+		providerType = providerType.Elem()
+	}
+	return providerType.Name()
+}
+
+// parseJobTimeout converts the value of SM_JOB_TIMEOUT, a number of seconds that leaves time to report the task result
+func parseJobTimeout(value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || time.Duration(seconds)*time.Second <= TaskReportTimeout {
+		return 0, fmt.Errorf("invalid value '%s' for SM_JOB_TIMEOUT. must be a number of seconds greater than %d", value, int(TaskReportTimeout.Seconds()))
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// RunTask performs the action of the secret task with provider and updates the task with the result.
+// It returns the error that made the task fail, which is already reported to Secrets Manager when possible.
+// If ctx expires before the provider is done, the task fails with ErrJobTimeout. If the provider panics, the task
+// fails with ErrProviderPanic, unless the task was already updated with the result of the action. If the provider
+// implements TriggerHooks, the hooks of the trigger of the task are called around the action.
+func RunTask(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider) (err error) {
+	// Once the task is completed, it cannot be failed anymore and the credentials are known to Secrets Manager
+	var updated bool
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if updated {
+				panicError(ctx, recovered)
+				LoggerFromContext(ctx).Warn("credentials provider panicked after the task was completed")
+				err = nil
+				return
+			}
+			err = reportPanic(ctx, client, config, provider, recovered)
+		}
+	}()
+
+	var runAction func(context.Context, SecretsManagerClient, *Config, CredentialsProvider, *bool) error
+	switch config.SM_ACTION {
+	case sm.SecretTask_Type_CreateCredentials:
+		runAction = runCreateCredentials
+	case sm.SecretTask_Type_DeleteCredentials:
+		runAction = runDeleteCredentials
+	default:
+		return reportTaskError(ctx, client, config, ErrUnknownAction, fmt.Errorf("unknown action: '%s'", config.SM_ACTION))
+	}
+
+	var hooks TaskHooks
+	if triggerHooks, ok := provider.(TriggerHooks); ok {
+		hooks = triggerHooks.Hooks()[config.SM_TRIGGER]
+	}
+	if hooks.Before != nil {
+		if err := hooks.Before(ctx, client, config); err != nil {
+			return reportTaskError(ctx, client, config, taskErrorCode(ctx, err, ErrTriggerHookFailed), fmt.Errorf("hook of trigger '%s' failed: %w", config.SM_TRIGGER, err))
+		}
+	}
+	if err := runAction(ctx, client, config, provider, &updated); err != nil {
+		return err
+	}
+	if hooks.After != nil {
+		runAfterHook(ctx, client, config, hooks.After)
+	}
+	return nil
+}
+
+// runAfterHook calls the After hook of a completed task. An error or a panic of the hook is only logged, because the
+// task cannot be failed anymore.
+func runAfterHook(ctx context.Context, client SecretsManagerClient, config *Config, hook TaskHook) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicError(ctx, recovered)
+			LoggerFromContext(ctx).Warn("hook panicked after the task was completed")
+		}
+	}()
+	if err := hook(ctx, client, config); err != nil {
+		LoggerFromContext(ctx).Warn("hook failed after the task was completed", "error", err)
+	}
+}
+
+// runCreateCredentials creates the credentials with provider and adds them to the task. It sets *updated once the
+// task was updated with them. The credentials are deleted again if the task cannot be updated, because Secrets
+// Manager does not know about them.
+func runCreateCredentials(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider, updated *bool) error {
+	credentialsPayload, credentialsID, err := provider.Create(ctx, client, config)
+	if err != nil {
+		return reportTaskError(ctx, client, config, taskErrorCode(ctx, err, ErrCredentialsNotCreated), fmt.Errorf("cannot create credentials: %w", err))
+	}
+	config.SM_CREDENTIALS_ID = credentialsID
+	runLogger := LoggerFromContext(ctx).With("credentials_id", credentialsID)
+	ctx = contextWithLogger(ctx, runLogger)
+	runLogger.Info("credentials were created")
+
+	result, err := UpdateTaskAboutCredentialsCreated(ctx, client, config, credentialsPayload)
+	if err != nil {
+		err = deleteCreatedCredentials(ctx, client, config, provider, fmt.Errorf("cannot update task: %w", err))
+		if ctx.Err() != nil {
+			// The task could not be updated in time, which can still be reported in the reserved time
+			return reportTaskError(ctx, client, config, ErrJobTimeout, err)
+		}
+		runLogger.Error("task failed", "error", err)
+		return err
+	}
+	*updated = true
+
+	runLogger.Info("task successfully updated: credentials were created", "updated_by", core.StringNilMapper(result.UpdatedBy))
+	return nil
+}
+
+// runDeleteCredentials deletes the credentials identified by config.SM_CREDENTIALS_ID with provider and updates the
+// task. It sets *updated once the task was updated.
+func runDeleteCredentials(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider, updated *bool) error {
+	if err := provider.Delete(ctx, client, config); err != nil {
+		return reportTaskError(ctx, client, config, taskErrorCode(ctx, err, ErrCredentialsNotDeleted), fmt.Errorf("cannot delete credentials with credentials id: '%s': %w", config.SM_CREDENTIALS_ID, err))
+	}
+
+	result, err := UpdateTaskAboutCredentialsDeleted(ctx, client, config)
+	if err != nil {
+		err = fmt.Errorf("cannot update task about deleted credentials with credentials id: '%s'. error: %w", config.SM_CREDENTIALS_ID, err)
+		if ctx.Err() != nil {
+			return reportTaskError(ctx, client, config, ErrJobTimeout, err)
+		}
+		LoggerFromContext(ctx).Error("task failed", "error", err)
+		return err
+	}
+	*updated = true
+
+	LoggerFromContext(ctx).Info("task successfully updated: credentials were deleted", "updated_by", core.StringNilMapper(result.UpdatedBy))
+	return nil
+}
+
+// deleteCreatedCredentials deletes the credentials created by the task, which Secrets Manager does not know about,
+// and adds the outcome to err. If they cannot be deleted, the returned TaskErrors also holds the error of Delete
+// with ErrCredentialsNotDeleted.
+func deleteCreatedCredentials(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider, err error) error {
+	deleteCtx, cancel := reportContext(ctx)
+	defer cancel()
+	if deleteErr := recoverDelete(deleteCtx, client, config, provider); deleteErr != nil {
+		var errs TaskErrors
+		errs.Add(err)
+		errs.Add(NewTaskError(ErrCredentialsNotDeleted, fmt.Errorf("cannot delete credentials with credentials id: '%s'. error: %w", config.SM_CREDENTIALS_ID, deleteErr)))
+		return errs.Err()
+	}
+	return fmt.Errorf("%w. credentials with credentials id: '%s' were deleted", err, config.SM_CREDENTIALS_ID)
+}
+
+// recoverDelete calls provider.Delete and returns a panic of the provider as an error
+func recoverDelete(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = panicError(ctx, recovered)
+		}
+	}()
+	return provider.Delete(ctx, client, config)
+}
+
+// reportPanic reports a panic of the provider as a task error with ErrProviderPanic. The credentials that were
+// already created by the task are deleted again.
+func reportPanic(ctx context.Context, client SecretsManagerClient, config *Config, provider CredentialsProvider, recovered any) error {
+	err := panicError(ctx, recovered)
+	if config.SM_ACTION == sm.SecretTask_Type_CreateCredentials && config.SM_CREDENTIALS_ID != "" {
+		err = deleteCreatedCredentials(ctx, client, config, provider, err)
+	}
+	return reportTaskError(ctx, client, config, ErrProviderPanic, err)
+}
+
+// panicError logs the stack trace of a recovered panic and converts the panic value into an error. The value is
+// redacted because the error is reported to Secrets Manager, the stack trace is only logged.
+func panicError(ctx context.Context, recovered any) error {
+	LoggerFromContext(ctx).Error("credentials provider panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+	return fmt.Errorf("unexpected error in the credentials provider: %s", Redact(fmt.Sprint(recovered)))
+}
+
+// taskErrorCode returns ErrJobTimeout if ctx expired, otherwise the code of err or fallback
+func taskErrorCode(ctx context.Context, err error, fallback string) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrJobTimeout
+	}
+	return ErrorCode(err, fallback)
+}
+
+// reportContext returns a context for the calls that report the result of the task, which have
+// TaskReportTimeout to complete even if ctx is already done
+func reportContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), TaskReportTimeout)
+}
+
+// reportTaskError logs err, updates the task about it with the given code and returns err. If err is a TaskErrors,
+// each of its errors is reported with its own code, and code is used for the errors without one.
+func reportTaskError(ctx context.Context, client SecretsManagerClient, config *Config, code string, err error) error {
+	LoggerFromContext(ctx).Error("task failed", "code", code, "retryable", ErrorCatalog[code].Retryable, "error", err)
+	reportCtx, cancel := reportContext(ctx)
+	defer cancel()
+	var errs *TaskErrors
+	if !errors.As(err, &errs) {
+		errs = &TaskErrors{}
+		errs.Add(NewTaskError(code, err))
+	}
+	result, taskErr := UpdateTaskAboutErrors(reportCtx, client, config, errs, code)
+	if taskErr != nil {
+		LoggerFromContext(ctx).Error("cannot update task about error", "code", code, "error", taskErr)
+	} else {
+		LoggerFromContext(ctx).Info("task was updated about error", "code", code, "updated_by", core.StringNilMapper(result.UpdatedBy))
+	}
+	return err
+}
+
+// Defaults of the dry-run settings, relative to the working directory of the job
+const (
+	DefaultDryRunEnvFile     = ".env"
+	DefaultDryRunFixturesDir = "fixtures"
+)
+
+// dryRunDefaults are the values of the service variables that a dry run uses when neither the environment nor the
+// env file sets them, so that the env file only needs the action, the trigger and the input variables
+var dryRunDefaults = map[string]string{
+	"SM_ACCESS_APIKEY":   "dry-run",
+	"SM_INSTANCE_URL":    "https://dry-run.invalid",
+	"SM_SECRET_GROUP_ID": "default",
+	"SM_SECRET_ID":       "dry-run-secret-id",
+	"SM_SECRET_NAME":     "dry-run",
+	"SM_SECRET_TASK_ID":  "dry-run-task-id",
+}
+
+// isDryRun reports whether SM_DRY_RUN enables the dry-run mode
+func isDryRun() bool {
+	dryRun, _ := strconv.ParseBool(GetEnvVar("SM_DRY_RUN"))
+	return dryRun
+}
+
+// RunDryRun runs the job like Run, without a Secrets Manager task. The configuration is read from the environment
+// and from the env file of SM_DRY_RUN_ENV_FILE, the secrets from the JSON fixture files in SM_DRY_RUN_FIXTURES, see
+// DryRunClient. The credentials and the task update that Run would send to Secrets Manager are written to w.
+// It returns the error that made the task fail.
+func RunDryRun(w io.Writer, provider CredentialsProvider) error {
+	envFile := GetEnvVar("SM_DRY_RUN_ENV_FILE")
+	err := loadEnvFile(cmp.Or(envFile, DefaultDryRunEnvFile))
+	if errors.Is(err, fs.ErrNotExist) && envFile == "" {
+		err = nil
+	}
+	for key, value := range dryRunDefaults {
+		if GetEnvVar(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
+
+	config, configErr := ConfigFromEnv()
+	logger = NewLogger(NewLogHandler(os.Stderr, config.SM_LOG_LEVEL), &config, providerName(provider))
+	if err != nil {
+		logger.Error("cannot load env file", "error", err)
+		return err
+	}
+
+	client := NewDryRunClient(w, config.SM_INSTANCE_URL, cmp.Or(GetEnvVar("SM_DRY_RUN_FIXTURES"), DefaultDryRunFixturesDir))
+	if configErr != nil {
+		return reportTaskError(context.Background(), client, &config, ErrInvalidJobConfig, fmt.Errorf("invalid job configuration: %w", configErr))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.SM_JOB_TIMEOUT-TaskReportTimeout)
+	defer cancel()
+	return RunTask(ctx, client, &config, provider)
+}
+
+// loadEnvFile sets the environment variables of an env file with KEY=VALUE lines. Empty lines, comment lines that
+// start with # and an export prefix are ignored, and a value can be enclosed in single or double quotes.
+// The variables that are already set to a non-empty value keep it, so that they can be overridden per run.
+func loadEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid line %d in env file %s. must be KEY=VALUE", lineNumber, path)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if GetEnvVar(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read env file %s: %w", path, err)
+	}
+	return nil
+}
+
+// DryRunClient is the SecretsManagerClient of a dry run. GetSecretWithContext reads the secret with ID <id> from
+// the fixture file <id>.json, which contains the secret as returned by the Secrets Manager API, e.g.
+// {"id": "<id>", "secret_type": "arbitrary", "payload": "..."}. ReplaceSecretTaskWithContext writes the request
+// that it would send to w instead, with the credentials redacted, see redactCredentials.
+type DryRunClient struct {
+	w           io.Writer
+	instanceURL string
+	fixturesDir string
+}
+
+// NewDryRunClient creates a DryRunClient that writes to w the requests to the instance of instanceURL and reads
+// the secrets from fixturesDir
+func NewDryRunClient(w io.Writer, instanceURL, fixturesDir string) *DryRunClient {
+	return &DryRunClient{w: w, instanceURL: strings.TrimSuffix(instanceURL, "/"), fixturesDir: fixturesDir}
+}
+
+func (c *DryRunClient) GetSecretWithContext(ctx context.Context, options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	id := core.StringNilMapper(options.ID)
+	path := filepath.Join(c.fixturesDir, id+".json")
+	data, err := os.ReadFile(path)
+	if !filepath.IsLocal(id) || errors.Is(err, fs.ErrNotExist) {
+		return nil, &core.DetailedResponse{StatusCode: http.StatusNotFound}, fmt.Errorf("secret with ID '%s' not found: no fixture file %s", id, path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read fixture file %s: %w", path, err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid fixture file %s: %w", path, err)
+	}
+	var secret sm.SecretIntf
+	if err := core.UnmarshalModel(raw, "", &secret, sm.UnmarshalSecret); err != nil {
+		return nil, nil, fmt.Errorf("invalid secret in fixture file %s: %w", path, err)
+	}
+	return secret, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+}
+
+func (c *DryRunClient) GetSecretTaskWithContext(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	// The task updates of a dry run are not sent, so the task is never in a final state
+	return nil, &core.DetailedResponse{StatusCode: http.StatusNotFound}, fmt.Errorf("secret task with ID '%s' not found: the tasks of a dry run are not stored", core.StringNilMapper(options.ID))
+}
+
+func (c *DryRunClient) ReplaceSecretTaskWithContext(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	var body map[string]interface{}
+	data, err := json.Marshal(options.TaskPut)
+	if err == nil {
+		err = json.Unmarshal(data, &body)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize the secret task update: %w", err)
+	}
+	if credentials, ok := body["credentials"].(map[string]interface{}); ok {
+		if payload, ok := credentials["payload"].(map[string]interface{}); ok {
+			credentials["payload"] = redactCredentials(payload)
+		}
+	}
+
+	requestURL := fmt.Sprintf("%s/api/v2/secrets/%s/tasks/%s", c.instanceURL, core.StringNilMapper(options.SecretID), core.StringNilMapper(options.ID))
+	if err := c.write("PUT "+requestURL+" (not sent)", body); err != nil {
+		return nil, nil, err
+	}
+	status, _ := body["status"].(string)
+	task := &sm.SecretTask{
+		ID:        options.ID,
+		SecretID:  options.SecretID,
+		Status:    core.StringPtr(status),
+		UpdatedBy: core.StringPtr("dry-run"),
+	}
+	return task, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+}
+
+func (c *DryRunClient) NewSecretTaskError(code, description string) (*sm.SecretTaskError, error) {
+	return &sm.SecretTaskError{Code: core.StringPtr(code), Description: core.StringPtr(description)}, nil
+}
+
+func (c *DryRunClient) NewCustomCredentialsNewCredentials(id string, credentials map[string]interface{}) (*sm.CustomCredentialsNewCredentials, error) {
+	if err := c.write(fmt.Sprintf("Credentials payload with credentials ID '%s'", id), redactCredentials(credentials)); err != nil {
+		return nil, err
+	}
+	return &sm.CustomCredentialsNewCredentials{ID: core.StringPtr(id), Payload: credentials}, nil
+}
+
+// write writes a title line followed by value as indented JSON
+func (c *DryRunClient) write(title string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot serialize %s: %w", title, err)
+	}
+	_, err = fmt.Fprintf(c.w, "%s:\n%s\n", title, data)
+	return err
+}
+
+// redactCredentials returns a copy of credentials in which the values of the sensitive fields, and the values in
+// which Redact finds a credential, are replaced. A value is replaced entirely because a partially redacted key or
+// token can still leak most of it.
+func redactCredentials(credentials map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(credentials))
+	for key, value := range credentials {
+		stringValue, isString := value.(string)
+		if sensitiveKeyPattern.MatchString(key) || (isString && Redact(stringValue) != stringValue) {
+			redacted[key] = redactedValue
+		} else {
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,266 @@
+package job
+
+// Auto-generated by secrets-manager-job-generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+)
+
+// MockSecretsManagerClient is a SecretsManagerClient for unit tests that records every call.
+// By default, GetSecretWithContext returns the secrets added with AddSecret, GetSecretTaskWithContext returns the
+// task with the status of the last ReplaceSecretTaskWithContext call, and the other methods succeed.
+// Like the SDK, the ...WithContext methods fail with the error of the context once it is done.
+// Set the Func fields to override the behavior of a method.
+type MockSecretsManagerClient struct {
+	GetSecretWithContextFunc               func(ctx context.Context, options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error)
+	GetSecretTaskWithContextFunc           func(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error)
+	ReplaceSecretTaskWithContextFunc       func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error)
+	NewSecretTaskErrorFunc                 func(code, description string) (*sm.SecretTaskError, error)
+	NewCustomCredentialsNewCredentialsFunc func(id string, credentials map[string]interface{}) (*sm.CustomCredentialsNewCredentials, error)
+
+	// Secrets returned by GetSecretWithContext, keyed by secret ID
+	Secrets map[string]sm.SecretIntf
+
+	// Recorded calls, in call order
+	GetSecretCalls                          []*sm.GetSecretOptions
+	GetSecretTaskCalls                      []*sm.GetSecretTaskOptions
+	ReplaceSecretTaskCalls                  []*sm.ReplaceSecretTaskOptions
+	NewSecretTaskErrorCalls                 []MockSecretTaskErrorCall
+	NewCustomCredentialsNewCredentialsCalls []MockNewCredentialsCall
+
+	taskStatus string // The status set by the last successful ReplaceSecretTaskWithContext call without Func
+	mu         sync.Mutex
+}
+
+// MockSecretTaskErrorCall holds the arguments of a NewSecretTaskError call
+type MockSecretTaskErrorCall struct {
+	Code        string
+	Description string
+}
+
+// MockNewCredentialsCall holds the arguments of a NewCustomCredentialsNewCredentials call
+type MockNewCredentialsCall struct {
+	ID          string
+	Credentials map[string]interface{}
+}
+
+// NewMockSecretsManagerClient creates a MockSecretsManagerClient with the default behavior
+func NewMockSecretsManagerClient() *MockSecretsManagerClient {
+	return &MockSecretsManagerClient{}
+}
+
+// AddSecret registers a secret that GetSecretWithContext returns for the given ID
+func (m *MockSecretsManagerClient) AddSecret(id string, secret sm.SecretIntf) *MockSecretsManagerClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Secrets == nil {
+		m.Secrets = make(map[string]sm.SecretIntf)
+	}
+	m.Secrets[id] = secret
+	return m
+}
+
+func (m *MockSecretsManagerClient) GetSecretWithContext(ctx context.Context, options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error) {
+	m.mu.Lock()
+	m.GetSecretCalls = append(m.GetSecretCalls, options)
+	secret, ok := m.Secrets[core.StringNilMapper(options.ID)]
+	m.mu.Unlock()
+
+	if m.GetSecretWithContextFunc != nil {
+		return m.GetSecretWithContextFunc(ctx, options)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, &core.DetailedResponse{StatusCode: http.StatusNotFound}, fmt.Errorf("secret with ID '%s' not found", core.StringNilMapper(options.ID))
+	}
+	return secret, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+}
+
+func (m *MockSecretsManagerClient) GetSecretTaskWithContext(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	m.mu.Lock()
+	m.GetSecretTaskCalls = append(m.GetSecretTaskCalls, options)
+	status := m.taskStatus
+	m.mu.Unlock()
+
+	if m.GetSecretTaskWithContextFunc != nil {
+		return m.GetSecretTaskWithContextFunc(ctx, options)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if status == "" {
+		return nil, &core.DetailedResponse{StatusCode: http.StatusNotFound}, fmt.Errorf("secret task with ID '%s' not found", core.StringNilMapper(options.ID))
+	}
+	task := &sm.SecretTask{
+		ID:       options.ID,
+		SecretID: options.SecretID,
+		Status:   core.StringPtr(status),
+	}
+	return task, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+}
+
+func (m *MockSecretsManagerClient) ReplaceSecretTaskWithContext(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	m.mu.Lock()
+	m.ReplaceSecretTaskCalls = append(m.ReplaceSecretTaskCalls, options)
+	m.mu.Unlock()
+
+	if m.ReplaceSecretTaskWithContextFunc != nil {
+		return m.ReplaceSecretTaskWithContextFunc(ctx, options)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	task := &sm.SecretTask{
+		ID:        options.ID,
+		SecretID:  options.SecretID,
+		Status:    core.StringPtr(taskPutStatus(options.TaskPut)),
+		UpdatedBy: core.StringPtr("mock"),
+	}
+	m.mu.Lock()
+	m.taskStatus = *task.Status
+	m.mu.Unlock()
+	return task, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+}
+
+func (m *MockSecretsManagerClient) NewSecretTaskError(code, description string) (*sm.SecretTaskError, error) {
+	m.mu.Lock()
+	m.NewSecretTaskErrorCalls = append(m.NewSecretTaskErrorCalls, MockSecretTaskErrorCall{Code: code, Description: description})
+	m.mu.Unlock()
+
+	if m.NewSecretTaskErrorFunc != nil {
+		return m.NewSecretTaskErrorFunc(code, description)
+	}
+	return &sm.SecretTaskError{Code: core.StringPtr(code), Description: core.StringPtr(description)}, nil
+}
+
+func (m *MockSecretsManagerClient) NewCustomCredentialsNewCredentials(id string, credentials map[string]interface{}) (*sm.CustomCredentialsNewCredentials, error) {
+	m.mu.Lock()
+	m.NewCustomCredentialsNewCredentialsCalls = append(m.NewCustomCredentialsNewCredentialsCalls, MockNewCredentialsCall{ID: id, Credentials: credentials})
+	m.mu.Unlock()
+
+	if m.NewCustomCredentialsNewCredentialsFunc != nil {
+		return m.NewCustomCredentialsNewCredentialsFunc(id, credentials)
+	}
+	return &sm.CustomCredentialsNewCredentials{ID: core.StringPtr(id), Payload: credentials}, nil
+}
+
+// LastReplaceSecretTask returns the options of the last ReplaceSecretTask call, or nil if it was not called
+func (m *MockSecretsManagerClient) LastReplaceSecretTask() *sm.ReplaceSecretTaskOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.ReplaceSecretTaskCalls) == 0 {
+		return nil
+	}
+	return m.ReplaceSecretTaskCalls[len(m.ReplaceSecretTaskCalls)-1]
+}
+
+// NewMockArbitrarySecret creates an arbitrary secret with the given payload
+func NewMockArbitrarySecret(id, payload string) *sm.ArbitrarySecret {
+	return &sm.ArbitrarySecret{
+		ID:         core.StringPtr(id),
+		Name:       core.StringPtr("mock-arbitrary-secret"),
+		SecretType: core.StringPtr(sm.Secret_SecretType_Arbitrary),
+		Payload:    core.StringPtr(payload),
+	}
+}
+
+// NewMockCustomCredentialsSecret creates a custom credentials secret with the given credentials content
+func NewMockCustomCredentialsSecret(id string, credentials map[string]interface{}) *sm.CustomCredentialsSecret {
+	return &sm.CustomCredentialsSecret{
+		ID:                 core.StringPtr(id),
+		Name:               core.StringPtr("mock-custom-credentials-secret"),
+		SecretType:         core.StringPtr(sm.Secret_SecretType_CustomCredentials),
+		CredentialsContent: credentials,
+	}
+}
+
+// NewMockServiceCredentialsSecret creates a service credentials secret with the given credentials properties
+func NewMockServiceCredentialsSecret(id string, credentials map[string]interface{}) *sm.ServiceCredentialsSecret {
+	serviceCredentials := &sm.ServiceCredentialsSecretCredentials{}
+	serviceCredentials.SetProperties(credentials)
+	return &sm.ServiceCredentialsSecret{
+		ID:          core.StringPtr(id),
+		Name:        core.StringPtr("mock-service-credentials-secret"),
+		SecretType:  core.StringPtr(sm.Secret_SecretType_ServiceCredentials),
+		Credentials: serviceCredentials,
+	}
+}
+
+// TestingT is the subset of testing.TB used by the assertion helpers
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCredentialsCreated checks that the last ReplaceSecretTask call reported the given credentials as created
+func (m *MockSecretsManagerClient) AssertCredentialsCreated(t TestingT, id string, credentials map[string]interface{}) bool {
+	t.Helper()
+	prototype, ok := m.lastTaskPut(t).(*sm.SecretTaskPrototypeUpdateSecretTaskCredentialsCreated)
+	if !ok {
+		t.Errorf("expected the last secret task update to report created credentials, got %T", m.lastTaskPut(t))
+		return false
+	}
+	if prototype.Credentials == nil {
+		t.Errorf("expected the last secret task update to contain credentials")
+		return false
+	}
+	if actualID := core.StringNilMapper(prototype.Credentials.ID); actualID != id {
+		t.Errorf("expected credentials ID '%s', got '%s'", id, actualID)
+		return false
+	}
+	expectedJSON, _ := json.Marshal(credentials)
+	actualJSON, _ := json.Marshal(prototype.Credentials.Payload)
+	if string(expectedJSON) != string(actualJSON) {
+		t.Errorf("expected credentials payload %s, got %s", expectedJSON, actualJSON)
+		return false
+	}
+	return true
+}
+
+// AssertCredentialsDeleted checks that the last ReplaceSecretTask call reported the credentials as deleted
+func (m *MockSecretsManagerClient) AssertCredentialsDeleted(t TestingT) bool {
+	t.Helper()
+	if _, ok := m.lastTaskPut(t).(*sm.SecretTaskPrototypeUpdateSecretTaskCredentialsDeleted); !ok {
+		t.Errorf("expected the last secret task update to report deleted credentials, got %T", m.lastTaskPut(t))
+		return false
+	}
+	return true
+}
+
+// AssertTaskFailed checks that the last ReplaceSecretTask call reported the task as failed with the given error code
+func (m *MockSecretsManagerClient) AssertTaskFailed(t TestingT, code string) bool {
+	t.Helper()
+	prototype, ok := m.lastTaskPut(t).(*sm.SecretTaskPrototypeUpdateSecretTaskFailed)
+	if !ok {
+		t.Errorf("expected the last secret task update to report a failure, got %T", m.lastTaskPut(t))
+		return false
+	}
+	var codes []string
+	for _, taskError := range prototype.Errors {
+		if core.StringNilMapper(taskError.Code) == code {
+			return true
+		}
+		codes = append(codes, core.StringNilMapper(taskError.Code))
+	}
+	t.Errorf("expected the last secret task update to contain error code '%s', got %v", code, codes)
+	return false
+}
+
+// lastTaskPut returns the payload of the last ReplaceSecretTask call, or nil if it was not called
+func (m *MockSecretsManagerClient) lastTaskPut(t TestingT) sm.SecretTaskPrototypeIntf {
+	t.Helper()
+	options := m.LastReplaceSecretTask()
+	if options == nil {
+		return nil
+	}
+	return options.TaskPut
+}
@@ -0,0 +1,314 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUpdateTask tests that the task updates sent to Secrets Manager carry the expected payload
+func TestUpdateTask(t *testing.T) {
+	config := Config{
+		SM_SECRET_ID:      "test-secret-id",
+		SM_SECRET_TASK_ID: "test-secret-task-id",
+		SM_CREDENTIALS_ID: "test-credentials-id",
+	}
+
+	t.Run("Credentials created", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		_, err := UpdateTaskAboutCredentialsCreated(context.Background(), mockClient, &config, CredentialsPayload{
+			PRIVATE_KEY_BASE64: "private-key",
+			CERTIFICATE_BASE64: "certificate",
+		})
+		assert.NoError(t, err)
+		mockClient.AssertCredentialsCreated(t, "test-credentials-id", map[string]interface{}{
+			"private_key_base64": "private-key",
+			"certificate_base64": "certificate",
+		})
+		assert.Equal(t, "test-secret-task-id", *mockClient.LastReplaceSecretTask().ID)
+	})
+
+	t.Run("Invalid credentials payload", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		_, err := UpdateTaskAboutCredentialsCreated(context.Background(), mockClient, &config, CredentialsPayload{
+			PRIVATE_KEY_BASE64: "private-key",
+		})
+		assert.Error(t, err)
+		mockClient.AssertTaskFailed(t, ErrCredentialsPayloadInvalid)
+	})
+
+	t.Run("Credentials payload too large", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		_, err := UpdateTaskAboutCredentialsCreated(context.Background(), mockClient, &config, CredentialsPayload{
+			PRIVATE_KEY_BASE64: strings.Repeat("k", 60000),
+			CERTIFICATE_BASE64: strings.Repeat("c", 60000),
+		})
+		assert.ErrorContains(t, err, "exceeds the limit")
+		mockClient.AssertTaskFailed(t, ErrCredentialsPayloadTooLarge)
+	})
+
+	t.Run("Task failed", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		_, err := UpdateTaskAboutError(context.Background(), mockClient, &config, "Err10001", "cannot generate certificate")
+		assert.NoError(t, err)
+		mockClient.AssertTaskFailed(t, "Err10001")
+	})
+}
+
+// TestUpdateTaskAboutErrors tests that the accumulated errors are reported together within the limits
+func TestUpdateTaskAboutErrors(t *testing.T) {
+	config := Config{SM_SECRET_ID: "test-secret-id", SM_SECRET_TASK_ID: "test-secret-task-id"}
+
+	t.Run("Errors with codes and remediation", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		var errs TaskErrors
+		errs.Add(WithRemediation(TaskErrorf("Err10001", "role already exists"), "delete the role or choose another name"))
+		errs.Add(errors.New("cannot close the connection"))
+
+		_, err := UpdateTaskAboutErrors(context.Background(), mockClient, &config, &errs, ErrCredentialsNotCreated)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []MockSecretTaskErrorCall{
+			{Code: "Err10001", Description: "role already exists. remediation: delete the role or choose another name"},
+			{Code: ErrCredentialsNotCreated, Description: "cannot close the connection"},
+		}, mockClient.NewSecretTaskErrorCalls)
+		mockClient.AssertTaskFailed(t, "Err10001")
+		mockClient.AssertTaskFailed(t, ErrCredentialsNotCreated)
+	})
+
+	t.Run("Remediation of the error catalog", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		var errs TaskErrors
+		errs.Add(NewTaskError(ErrJobTimeout, context.DeadlineExceeded))
+		errs.Add(WithRemediation(NewCertificateNotCreatedError("42", errors.New("x509: unsupported key")), "use an RSA key"))
+
+		_, err := UpdateTaskAboutErrors(context.Background(), mockClient, &config, &errs, ErrCredentialsNotCreated)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []MockSecretTaskErrorCall{
+			{Code: ErrJobTimeout, Description: "context deadline exceeded. remediation: increase the max execution time of the job and SM_JOB_TIMEOUT"},
+			{Code: ErrCertificateNotCreated, Description: "cannot create certificate with serial number: '42'. error: x509: unsupported key. remediation: use an RSA key"},
+		}, mockClient.NewSecretTaskErrorCalls)
+	})
+
+	t.Run("Long description is truncated", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		var errs TaskErrors
+		errs.Add(errors.New(strings.Repeat("é", MaxTaskErrorDescriptionLength+1)))
+
+		_, err := UpdateTaskAboutErrors(context.Background(), mockClient, &config, &errs, "Err10001")
+
+		assert.NoError(t, err)
+		description := mockClient.NewSecretTaskErrorCalls[0].Description
+		assert.Len(t, []rune(description), MaxTaskErrorDescriptionLength)
+		assert.True(t, strings.HasSuffix(description, "éé..."))
+	})
+
+	t.Run("Too many errors are summarized", func(t *testing.T) {
+		mockClient := NewMockSecretsManagerClient()
+		var errs TaskErrors
+		for i := 1; i <= MaxTaskErrors+2; i++ {
+			errs.Add(TaskErrorf(fmt.Sprintf("Err100%02d", i), "error %d", i))
+		}
+
+		_, err := UpdateTaskAboutErrors(context.Background(), mockClient, &config, &errs, ErrCredentialsNotCreated)
+
+		assert.NoError(t, err)
+		calls := mockClient.NewSecretTaskErrorCalls
+		assert.Len(t, calls, MaxTaskErrors)
+		assert.Equal(t, MockSecretTaskErrorCall{Code: "Err10010", Description: "3 more errors, see the logs of the job run. the first one is: error 10"}, calls[MaxTaskErrors-1])
+		assert.Equal(t, MaxTaskErrors+2, errs.Len(), "the accumulated errors should not be changed")
+	})
+}
+
+// setFastRetries replaces TaskUpdateRetryPolicy with a policy that retries without waiting for the duration of the test
+func setFastRetries(t *testing.T) {
+	policy := TaskUpdateRetryPolicy
+	TaskUpdateRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	t.Cleanup(func() { TaskUpdateRetryPolicy = policy })
+}
+
+// replaceSecretTaskResponses returns a ReplaceSecretTaskWithContextFunc that answers the calls with the given status
+// codes in order. Status code 0 stands for a network error.
+func replaceSecretTaskResponses(headers http.Header, statusCodes ...int) func(context.Context, *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+	call := 0
+	return func(ctx context.Context, options *sm.ReplaceSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+		statusCode := statusCodes[min(call, len(statusCodes)-1)]
+		call++
+		switch {
+		case statusCode == 0:
+			return nil, nil, errors.New("connection reset by peer")
+		case statusCode != http.StatusOK:
+			return nil, &core.DetailedResponse{StatusCode: statusCode, Headers: headers}, errors.New(http.StatusText(statusCode))
+		}
+		return &sm.SecretTask{ID: options.ID}, &core.DetailedResponse{StatusCode: statusCode}, nil
+	}
+}
+
+// TestUpdateTaskRetries tests that UpdateTask retries transient failures according to TaskUpdateRetryPolicy
+func TestUpdateTaskRetries(t *testing.T) {
+	config := Config{SM_SECRET_ID: "test-secret-id", SM_SECRET_TASK_ID: "test-secret-task-id"}
+
+	testCases := []struct {
+		name          string
+		statusCodes   []int
+		taskStatus    string // The status of the task read back after a conflict, empty if the task is not found
+		expectedCalls int
+		expectedError string
+	}{
+		{
+			name:          "Service unavailable",
+			statusCodes:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Too many requests",
+			statusCodes:   []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			expectedCalls: 3,
+		},
+		{
+			name:          "Network error",
+			statusCodes:   []int{0, http.StatusOK},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Task already in final state after a lost response",
+			statusCodes:   []int{0, http.StatusConflict},
+			taskStatus:    sm.SecretTask_Status_CredentialsDeleted,
+			expectedCalls: 2,
+		},
+		{
+			name:          "Task completed with another status by another update",
+			statusCodes:   []int{0, http.StatusConflict},
+			taskStatus:    sm.SecretTask_Status_Failed,
+			expectedCalls: 2,
+			expectedError: "the secret task was completed with status 'failed' instead of 'credentials_deleted' by another update",
+		},
+		{
+			name:          "Task in final state cannot be read",
+			statusCodes:   []int{0, http.StatusConflict},
+			expectedCalls: 2,
+			expectedError: "cannot read the secret task in a final state",
+		},
+		{
+			name:          "Task already in final state on the first call",
+			statusCodes:   []int{http.StatusConflict},
+			expectedCalls: 1,
+			expectedError: "Conflict",
+		},
+		{
+			name:          "Bad request is not retried",
+			statusCodes:   []int{http.StatusBadRequest},
+			expectedCalls: 1,
+			expectedError: "Bad Request",
+		},
+		{
+			name:          "Retries exhausted",
+			statusCodes:   []int{http.StatusInternalServerError},
+			expectedCalls: 3,
+			expectedError: "Internal Server Error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setFastRetries(t)
+			mockClient := NewMockSecretsManagerClient()
+			mockClient.ReplaceSecretTaskWithContextFunc = replaceSecretTaskResponses(nil, tc.statusCodes...)
+			if tc.taskStatus != "" {
+				mockClient.GetSecretTaskWithContextFunc = func(ctx context.Context, options *sm.GetSecretTaskOptions) (*sm.SecretTask, *core.DetailedResponse, error) {
+					return &sm.SecretTask{ID: options.ID, Status: core.StringPtr(tc.taskStatus)}, &core.DetailedResponse{StatusCode: http.StatusOK}, nil
+				}
+			}
+
+			result, err := UpdateTaskAboutCredentialsDeleted(context.Background(), mockClient, &config)
+
+			assert.Len(t, mockClient.ReplaceSecretTaskCalls, tc.expectedCalls)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "test-secret-task-id", *result.ID)
+		})
+	}
+
+	t.Run("Limits from the configuration", func(t *testing.T) {
+		setFastRetries(t)
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = replaceSecretTaskResponses(nil, http.StatusInternalServerError)
+		limitedConfig := config
+		limitedConfig.SM_TASK_UPDATE_MAX_ATTEMPTS = 5
+
+		_, err := UpdateTaskAboutCredentialsDeleted(context.Background(), mockClient, &limitedConfig)
+
+		assert.ErrorContains(t, err, "Internal Server Error")
+		assert.Len(t, mockClient.ReplaceSecretTaskCalls, 5)
+	})
+
+	t.Run("Retry-After header", func(t *testing.T) {
+		setFastRetries(t)
+		TaskUpdateRetryPolicy.InitialBackoff = time.Hour
+		TaskUpdateRetryPolicy.MaxBackoff = time.Hour
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = replaceSecretTaskResponses(http.Header{"Retry-After": []string{"0"}}, http.StatusTooManyRequests, http.StatusOK)
+
+		_, err := UpdateTaskAboutCredentialsDeleted(context.Background(), mockClient, &config)
+
+		assert.NoError(t, err)
+		assert.Len(t, mockClient.ReplaceSecretTaskCalls, 2)
+	})
+
+	t.Run("Context done while waiting", func(t *testing.T) {
+		setFastRetries(t)
+		TaskUpdateRetryPolicy.InitialBackoff = time.Hour
+		TaskUpdateRetryPolicy.MaxBackoff = time.Hour
+		mockClient := NewMockSecretsManagerClient()
+		mockClient.ReplaceSecretTaskWithContextFunc = replaceSecretTaskResponses(nil, http.StatusServiceUnavailable)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := UpdateTaskAboutCredentialsDeleted(ctx, mockClient, &config)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Len(t, mockClient.ReplaceSecretTaskCalls, 1)
+	})
+}
+
+// TestRetryAfter tests that the Retry-After header is read in seconds and as an HTTP date
+func TestRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expectedWait  time.Duration
+		expectedFound bool
+	}{
+		{name: "Seconds", value: "7", expectedWait: 7 * time.Second, expectedFound: true},
+		{name: "Date in the past", value: "Sun, 06 Nov 1994 08:49:37 GMT", expectedWait: 0, expectedFound: true},
+		{name: "Not set", value: "", expectedFound: false},
+		{name: "Invalid", value: "soon", expectedFound: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := &core.DetailedResponse{Headers: http.Header{}}
+			if tc.value != "" {
+				response.Headers.Set("Retry-After", tc.value)
+			}
+
+			wait, found := retryAfter(response)
+
+			assert.Equal(t, tc.expectedFound, found)
+			assert.Equal(t, tc.expectedWait, wait)
+		})
+	}
+}
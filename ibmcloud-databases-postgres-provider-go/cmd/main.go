@@ -5,5 +5,5 @@ import (
 )
 
 func main() {
-	job.Run()
+	job.Run(job.NewPostgresProvider())
 }
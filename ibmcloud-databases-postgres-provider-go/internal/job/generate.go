@@ -0,0 +1,8 @@
+package job
+
+// Regenerate the secrets_manager_job*.go files and the README tables from job_config.json and error_codes.json with "go generate -skip verify ./...",
+// or check that they are up to date with "go generate -run verify ./...".
+//go:generate go run -C ../../../tools . -jobdir=../ibmcloud-databases-postgres-provider-go -jobfiledir=../ibmcloud-databases-postgres-provider-go/internal/job -force
+//go:generate go run -C ../../../tools . -verify -jobdir=../ibmcloud-databases-postgres-provider-go -jobfiledir=../ibmcloud-databases-postgres-provider-go/internal/job
+//go:generate go run -C ../../../tools . readme -jobdir=../ibmcloud-databases-postgres-provider-go
+//go:generate go run -C ../../../tools . readme -verify -jobdir=../ibmcloud-databases-postgres-provider-go
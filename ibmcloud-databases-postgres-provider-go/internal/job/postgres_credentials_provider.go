@@ -7,16 +7,11 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"net/url"
-	"os"
-	"postgres-credentials-provider/internal/utils"
 	"strconv"
 	"strings"
 
-	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
-
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -34,129 +29,76 @@ type pgAssembly struct {
 	compose           string
 }
 
-// Global logger instance
-var logger *utils.Logger
-
-func Run() {
-
-	// Setup
-	config, err := ConfigFromEnv()
-	if err != nil {
-		log.Fatalf("Failed to create config: %v", err)
-	}
+// PostgresProvider creates and deletes read-only roles of a PostgreSQL schema
+type PostgresProvider struct{}
 
-	client, err := NewSecretsManagerClient(config)
-	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
-	}
-
-	logger = utils.NewLogger(config.SM_SECRET_TASK_ID, config.SM_ACTION)
-
-	// Perform action
-	switch config.SM_ACTION {
-	case sm.SecretTask_Type_CreateCredentials:
-		generatePGCredentials(client, &config)
-	case sm.SecretTask_Type_DeleteCredentials:
-		deletePGCredentials(client, &config)
-	default:
-		updateTaskAboutErrorAndExit(client, &config, Err10000, fmt.Sprintf("unknown action: '%s'", config.SM_ACTION))
-	}
+// NewPostgresProvider creates a PostgresProvider
+func NewPostgresProvider() *PostgresProvider {
+	return &PostgresProvider{}
 }
 
-// generatePGCredentials generates postgres credentials for a given schema.
-func generatePGCredentials(client SecretsManagerClient, config *Config) {
-
-	// Set default values for non required config variables if not set by the user
-	setDefaultValues(config)
-
-	pg, err := obtainPGAssembly(client, config)
+// Create creates a read-only role for the configured schema and returns its credentials with the role OID as the credentials ID
+func (p *PostgresProvider) Create(ctx context.Context, client SecretsManagerClient, config *Config) (CredentialsPayload, string, error) {
+	pg, err := obtainPGAssembly(ctx, client, config)
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10001, fmt.Sprintf("error: %s", err.Error()))
+		return CredentialsPayload{}, "", NewDatabaseUnavailableError(err)
 	}
 	defer pg.dbPool.Close()
 
 	composedUrl, err := url.Parse(pg.compose)
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10002, fmt.Sprintf("cannot parse postgres composed url: '%s' url. error:%s", pg.compose, err))
+		return CredentialsPayload{}, "", NewComposedURLInvalidError(err)
 	}
 
 	password, err := generateRolePassword(64) // Generate a 64-character password
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10003, fmt.Sprintf("cannot generate a new password: %s", err))
+		return CredentialsPayload{}, "", NewPasswordNotGeneratedError(err)
 	}
 
 	roleName := generateRoleName()
 	schemaName := config.SM_SCHEMA_NAME
 
-	roleOID, err := createReadOnlyRole(pg.dbPool, roleName, password, schemaName)
+	roleOID, err := createReadOnlyRole(ctx, pg.dbPool, roleName, password, schemaName)
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10004, fmt.Sprintf("cannot generate a new postgres role for schema:'%s'. error: %s", schemaName, err))
+		return CredentialsPayload{}, "", NewRoleNotCreatedError(schemaName, err)
 	}
 
-	logger.Info(fmt.Sprintf("created role oid: %d for schema '%s'", roleOID, schemaName))
+	LoggerFromContext(ctx).Info("created role", "role_oid", roleOID, "schema", schemaName)
 
 	composedUrl.User = url.UserPassword(roleName, password)
 
-	config.SM_CREDENTIALS_ID = uint32ToString(roleOID)
-
 	credentialsPayload := CredentialsPayload{
 		CERTIFICATE_BASE64: pg.certificateBase64,
 		USERNAME:           roleName,
 		PASSWORD:           password,
 		COMPOSED:           composedUrl.String(),
 	}
-
-	result, err := UpdateTaskAboutCredentialsCreated(client, config, credentialsPayload)
-	if err != nil {
-		var errBuilder strings.Builder
-		errBuilder.WriteString(fmt.Sprintf("cannot update task: %s", err.Error()))
-		err = deleteReadOnlyRole(pg.dbPool, roleOID, schemaName)
-		if err != nil {
-			errBuilder.WriteString(fmt.Sprintf("cannot undo the creation of role with id: '%s'. error: %s", config.SM_CREDENTIALS_ID, err.Error()))
-		} else {
-			errBuilder.WriteString(fmt.Sprintf("role with id: '%s' was deleted. ", config.SM_CREDENTIALS_ID))
-		}
-		logger.Error(errors.New(errBuilder.String()))
-		os.Exit(1)
-	}
-
-	logger.Info(fmt.Sprintf("task successfully updated: role with id: '%s' was created by: %s ", config.SM_CREDENTIALS_ID, *result.UpdatedBy))
+	return credentialsPayload, uint32ToString(roleOID), nil
 }
 
-// deletePGCredentials deletes postgres credentials from the database.
-func deletePGCredentials(client SecretsManagerClient, config *Config) {
-	setDefaultValues(config)
+// Delete deletes the role identified by config.SM_CREDENTIALS_ID from the database
+func (p *PostgresProvider) Delete(ctx context.Context, client SecretsManagerClient, config *Config) error {
 	roleOID, err := stringToUint32(config.SM_CREDENTIALS_ID)
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10022, fmt.Sprintf("cannot convert credentials id: '%s' to int: %s", config.SM_CREDENTIALS_ID, err.Error()))
+		return NewCredentialsIDInvalidError(config.SM_CREDENTIALS_ID, err)
 	}
 
-	pg, err := obtainPGAssembly(client, config)
+	pg, err := obtainPGAssembly(ctx, client, config)
 	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10023, err.Error())
+		return NewDatabaseUnavailableForDeleteError(err)
 	}
-
 	defer pg.dbPool.Close()
-	schemaName := config.SM_SCHEMA_NAME
-	err = deleteReadOnlyRole(pg.dbPool, roleOID, schemaName)
-	if err != nil {
-		updateTaskAboutErrorAndExit(client, config, Err10024, fmt.Sprintf("cannot delete postgres role for schema:'%s'. error: %s", schemaName, err))
-	}
 
-	result, err := UpdateTaskAboutCredentialsDeleted(client, config)
-	if err != nil {
-		logger.Error(fmt.Errorf("cannot update task about credentials deleted with role id: '%s'. error: %s. ", config.SM_CREDENTIALS_ID, err.Error()))
-		os.Exit(1)
+	schemaName := config.SM_SCHEMA_NAME
+	if err := deleteReadOnlyRole(ctx, pg.dbPool, roleOID, schemaName); err != nil {
+		return NewRoleNotDeletedError(schemaName, err)
 	}
-
-	logger.Info(fmt.Sprintf("task successfully updated: role id: '%s' was deleted by: %s ", config.SM_CREDENTIALS_ID, *result.UpdatedBy))
+	return nil
 }
 
 // createReadOnlyRole creates a read-only role with the specified name and password in the given schema.
 // It returns the OID of the created role.
-func createReadOnlyRole(pool *pgxpool.Pool, roleName, password, schemaName string) (uint32, error) {
-
-	ctx := context.Background()
+func createReadOnlyRole(ctx context.Context, pool *pgxpool.Pool, roleName, password, schemaName string) (uint32, error) {
 	tx, err := pool.Begin(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("cannot begin transaction: %w", err)
@@ -209,10 +151,7 @@ func createReadOnlyRole(pool *pgxpool.Pool, roleName, password, schemaName strin
 // deleteReadOnlyRole deletes a role with the specified OID from the specified schema.
 // It revokes all privileges on the schema and all tables in the schema from the role,
 // and then drops the role if it exists.
-func deleteReadOnlyRole(pool *pgxpool.Pool, roleOID uint32, schemaName string) error {
-	// Use a context that could be passed from the caller if desired.
-	ctx := context.Background()
-
+func deleteReadOnlyRole(ctx context.Context, pool *pgxpool.Pool, roleOID uint32, schemaName string) error {
 	// Begin a transaction
 	tx, err := pool.Begin(ctx)
 	if err != nil {
@@ -225,7 +164,7 @@ func deleteReadOnlyRole(pool *pgxpool.Pool, roleOID uint32, schemaName string) e
 	var roleName string
 	if err = tx.QueryRow(ctx, "SELECT rolname FROM pg_roles WHERE oid = $1;", roleOID).Scan(&roleName); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) || strings.Contains(err.Error(), "no rows") {
-			logger.Info(fmt.Sprintf("no operation required, role with oid '%d' not found", roleOID))
+			LoggerFromContext(ctx).Info("no operation required, role not found", "role_oid", roleOID)
 			return nil
 		}
 		return fmt.Errorf("error checking role with oid '%d' existence: %w", roleOID, err)
@@ -252,7 +191,7 @@ func deleteReadOnlyRole(pool *pgxpool.Pool, roleOID uint32, schemaName string) e
 		return fmt.Errorf("cannot commit transaction: %w", err)
 	}
 
-	logger.Info(fmt.Sprintf("Role with oid '%d' dropped successfully for schema '%s'", roleOID, schemaName))
+	LoggerFromContext(ctx).Info("role dropped successfully", "role_oid", roleOID, "schema", schemaName)
 	return nil
 }
 
@@ -266,8 +205,8 @@ func quoteLiteral(literal string) string {
 	return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
 }
 
-func obtainPGAssembly(client SecretsManagerClient, config *Config) (*pgAssembly, error) {
-	sc, err := fetchPGServiceCredentials(client, config)
+func obtainPGAssembly(ctx context.Context, client SecretsManagerClient, config *Config) (*pgAssembly, error) {
+	sc, err := fetchPGServiceCredentials(ctx, client, config)
 	if err != nil {
 		return nil, err
 	}
@@ -287,7 +226,7 @@ func obtainPGAssembly(client SecretsManagerClient, config *Config) (*pgAssembly,
 		return nil, fmt.Errorf("postgres composed was not found in path: '%s'", composedPath)
 	}
 
-	dbPool, err := connectToPostgres(composed.(string), certificate)
+	dbPool, err := connectToPostgres(ctx, composed.(string), certificate)
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to postgres. error: %s", err)
 	}
@@ -301,7 +240,7 @@ func obtainPGAssembly(client SecretsManagerClient, config *Config) (*pgAssembly,
 
 // connectToPostgres establishes a connection to a PostgreSQL database using a provided connection string
 // and a TLS certificate file for secure communication.
-func connectToPostgres(connStr string, certificate []byte) (*pgxpool.Pool, error) {
+func connectToPostgres(ctx context.Context, connStr string, certificate []byte) (*pgxpool.Pool, error) {
 
 	// Create a certificate pool and add the certificate
 	rootCAs := x509.NewCertPool()
@@ -319,7 +258,7 @@ func connectToPostgres(connStr string, certificate []byte) (*pgxpool.Pool, error
 	config.ConnConfig.TLSConfig.RootCAs = rootCAs
 
 	// Create a connection pool
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create postgres connection pool: %w", err)
 	}
@@ -327,22 +266,13 @@ func connectToPostgres(connStr string, certificate []byte) (*pgxpool.Pool, error
 	return pool, nil
 }
 
-func fetchPGServiceCredentials(client SecretsManagerClient, config *Config) (sc map[string]interface{}, err error) {
-
-	secret, err := GetSecret(client, config.SM_LOGIN_SECRET_ID)
+// fetchPGServiceCredentials fetches the service credentials of the PostgreSQL deployment from Secrets Manager
+func fetchPGServiceCredentials(ctx context.Context, client SecretsManagerClient, config *Config) (map[string]interface{}, error) {
+	secret, err := ResolveLoginSecretId(ctx, client, config)
 	if err != nil {
-		if strings.Contains(err.Error(), "Provided API key could not be found") {
-			logger.Error(fmt.Errorf("cannot call the secrets manager service: %v", err))
-			os.Exit(1)
-		}
 		return nil, err
 	}
-	serviceCredentialsSecret, ok := secret.(*sm.ServiceCredentialsSecret)
-	if !ok {
-		return nil, fmt.Errorf("get secret id: '%s' returned unexpected secret type: %T, expected service credentials type", config.SM_LOGIN_SECRET_ID, secret)
-	}
-
-	return serviceCredentialsSecret.Credentials.GetProperties(), nil
+	return secret.Fields, nil
 }
 
 func generateRoleName() string {
@@ -370,24 +300,6 @@ func generateRolePassword(length int) (string, error) {
 	return string(password), nil
 }
 
-func updateTaskAboutErrorAndExit(client SecretsManagerClient, config *Config, code, description string) {
-	result, err := UpdateTaskAboutError(client, config, code, description)
-	if err != nil {
-		logger.Error(fmt.Errorf("cannot update task about error with code: '%s' and description: '%s'. returned error: %w", code, description, err))
-	} else {
-		logger.Info(fmt.Sprintf("task was updated about error with code: '%s' and description: '%s' by: %s", code, description, *result.UpdatedBy))
-	}
-	os.Exit(1)
-}
-
-// setDefaultValues sets default values for non required config variables if not set by the user
-func setDefaultValues(config *Config) {
-	// optional env variable, use postgres default 'public' schema as default
-	if config.SM_SCHEMA_NAME == "" {
-		config.SM_SCHEMA_NAME = "public"
-	}
-}
-
 // Uint32ToString converts a uint32 to a string.
 func uint32ToString(value uint32) string {
 	return strconv.FormatUint(uint64(value), 10)
@@ -3,5 +3,5 @@ package main
 import "ibmcloud-iam-user-apikey-provider-go/internal/job"
 
 func main() {
-	job.Run()
+	job.Run(job.NewApiKeyProvider())
 }
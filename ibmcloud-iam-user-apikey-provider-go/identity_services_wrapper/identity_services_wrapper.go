@@ -1,6 +1,7 @@
 package identity_services_wrapper
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/IBM/go-sdk-core/v5/core"
@@ -20,8 +21,8 @@ It exposes 2 functions:
 */
 
 type Wrapper interface {
-	CreateApiKey(options *CreateOptions) (*ApiKey, error)
-	DeleteApiKey(apikeyId string) error
+	CreateApiKey(ctx context.Context, options *CreateOptions) (*ApiKey, error)
+	DeleteApiKey(ctx context.Context, apikeyId string) error
 }
 
 type wrapper struct {
@@ -67,8 +68,8 @@ func New(url string, apikey string) (Wrapper, error) {
 }
 
 // CreateApiKey creates a new API with the given options. The API key will be created enabled and locked.
-func (w *wrapper) CreateApiKey(options *CreateOptions) (*ApiKey, error) {
-	resultApiKey, _, err := w.client.CreateAPIKey(buildOptions(options))
+func (w *wrapper) CreateApiKey(ctx context.Context, options *CreateOptions) (*ApiKey, error) {
+	resultApiKey, _, err := w.client.CreateAPIKeyWithContext(ctx, buildOptions(options))
 	if err != nil {
 		return nil, err
 	}
@@ -83,8 +84,8 @@ func (w *wrapper) CreateApiKey(options *CreateOptions) (*ApiKey, error) {
 
 // DeleteApiKey deletes the given API key associated with the given ID if it exists.
 // if the API key isn't found returns nil without making any changes.
-func (w *wrapper) DeleteApiKey(apikeyId string) error {
-	found, err := w.unlockApiKey(apikeyId)
+func (w *wrapper) DeleteApiKey(ctx context.Context, apikeyId string) error {
+	found, err := w.unlockApiKey(ctx, apikeyId)
 	if err != nil {
 		return err
 	}
@@ -93,7 +94,7 @@ func (w *wrapper) DeleteApiKey(apikeyId string) error {
 		return nil
 	}
 	deleteAPIKeyOptions := w.client.NewDeleteAPIKeyOptions(apikeyId)
-	_, err = w.client.DeleteAPIKey(deleteAPIKeyOptions)
+	_, err = w.client.DeleteAPIKeyWithContext(ctx, deleteAPIKeyOptions)
 	return err
 }
 
@@ -115,9 +116,9 @@ func buildOptions(options *CreateOptions) *iamidentityv1.CreateAPIKeyOptions {
 }
 
 // unlocks and API key if it exists. Returns a boolean value indicating the existence of the API key
-func (w *wrapper) unlockApiKey(apikeyID string) (bool, error) {
+func (w *wrapper) unlockApiKey(ctx context.Context, apikeyID string) (bool, error) {
 	unlockAPIKeyOptions := w.client.NewUnlockAPIKeyOptions(apikeyID)
-	resp, err := w.client.UnlockAPIKey(unlockAPIKeyOptions)
+	resp, err := w.client.UnlockAPIKeyWithContext(ctx, unlockAPIKeyOptions)
 
 	if err == nil && resp != nil && resp.StatusCode == 204 {
 		// the API key was found and was successfully unlocked
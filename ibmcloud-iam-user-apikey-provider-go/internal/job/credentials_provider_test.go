@@ -0,0 +1,210 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+)
+
+const (
+	identityAPIKey  = "identity-apikey"
+	createdAPIKeyID = "ApiKey-0b5571f7-21e6-42b7-91c5-3f5ac9793a46"
+)
+
+// fakeIdentityServer fakes the IAM token service and the API key endpoints of IAM Identity Services.
+// It issues access tokens for identityAPIKey only and records the API key requests.
+type fakeIdentityServer struct {
+	*httptest.Server
+
+	createStatus int // The status code of the API key creation, 201 if not set
+
+	mu       sync.Mutex
+	requests []string
+}
+
+// newFakeIdentityServer starts a fakeIdentityServer that is closed at the end of the test
+func newFakeIdentityServer(t *testing.T, createStatus int) *fakeIdentityServer {
+	s := &fakeIdentityServer{createStatus: createStatus}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /identity/token", s.issueToken)
+	mux.HandleFunc("POST /v1/apikeys", s.handleAPIKey(s.createAPIKey))
+	mux.HandleFunc("DELETE /v1/apikeys/{id}/lock", s.handleAPIKey(s.apikeyExists))
+	mux.HandleFunc("DELETE /v1/apikeys/{id}", s.handleAPIKey(s.apikeyExists))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests returns the API key requests, e.g. "POST /v1/apikeys"
+func (s *fakeIdentityServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *fakeIdentityServer) issueToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("apikey") != identityAPIKey {
+		writeIdentityJSON(w, http.StatusBadRequest, map[string]interface{}{"errorMessage": "provided API key could not be found"})
+		return
+	}
+	writeIdentityJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "identity-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"expiration":   time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+// handleAPIKey records an API key request and rejects it without the access token of identityAPIKey
+func (s *fakeIdentityServer) handleAPIKey(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer identity-access-token" {
+			writeIdentityJSON(w, http.StatusUnauthorized, map[string]interface{}{"errors": []map[string]string{{"code": "unauthorized"}}})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *fakeIdentityServer) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	if s.createStatus != 0 && s.createStatus != http.StatusCreated {
+		writeIdentityJSON(w, s.createStatus, map[string]interface{}{"errors": []map[string]string{{"code": "forbidden", "message": "not allowed to create API keys"}}})
+		return
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeIdentityJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []map[string]string{{"code": "bad_request"}}})
+		return
+	}
+	writeIdentityJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":         createdAPIKeyID,
+		"crn":        "crn:v1:bluemix:public:iam-identity::a/test-account-id::apikey:" + createdAPIKeyID,
+		"name":       body["name"],
+		"iam_id":     body["iam_id"],
+		"account_id": body["account_id"],
+		"apikey":     "created-apikey",
+		"locked":     true,
+	})
+}
+
+// apikeyExists answers the unlock and delete requests of the API key created by the server, and 404 for the others
+func (s *fakeIdentityServer) apikeyExists(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != createdAPIKeyID {
+		writeIdentityJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []map[string]string{{"code": "not_found"}}})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeIdentityJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newEndToEndConfig starts a FakeSecretsManagerServer that serves the API key secret and returns it with a job
+// configuration that points to it and to identityServer
+func newEndToEndConfig(t *testing.T, identityServer *fakeIdentityServer, action, credentialsID string) (*FakeSecretsManagerServer, Config) {
+	server := NewFakeSecretsManagerServer().
+		AddSecret("apikey-secret-id", NewMockArbitrarySecret("apikey-secret-id", identityAPIKey))
+	t.Cleanup(server.Close)
+	config := Config{
+		SM_ACTION:           action,
+		SM_SECRET_ID:        "test-secret-id",
+		SM_SECRET_NAME:      "test-secret-name",
+		SM_SECRET_TASK_ID:   "test-secret-task-id",
+		SM_CREDENTIALS_ID:   credentialsID,
+		SM_APIKEY_SECRET_ID: "apikey-secret-id",
+		SM_IAM_ID:           "IBMid-test",
+		SM_ACCOUNT_ID:       "test-account-id",
+		SM_URL:              identityServer.URL,
+	}
+	server.Configure(&config)
+	return server, config
+}
+
+// TestRunTaskEndToEnd tests the job with the SDK clients against FakeSecretsManagerServer, which serves the API key
+// secret, and a fake of IAM Identity Services
+func TestRunTaskEndToEnd(t *testing.T) {
+	t.Run("Create credentials", func(t *testing.T) {
+		identityServer := newFakeIdentityServer(t, http.StatusCreated)
+		server, config := newEndToEndConfig(t, identityServer, sm.SecretTask_Type_CreateCredentials, "")
+		client, err := NewSecretsManagerClient(config)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		err = RunTask(context.Background(), client, &config, NewApiKeyProvider())
+
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		if requests := identityServer.Requests(); !slices.Equal(requests, []string{"POST /v1/apikeys"}) {
+			t.Errorf("Expected the API key to be created, got requests %q", requests)
+		}
+		updates := server.TaskUpdates()
+		if len(updates) != 1 {
+			t.Fatalf("Expected 1 task update, got %d", len(updates))
+		}
+		if updates[0].To != sm.SecretTask_Status_CredentialsCreated || updates[0].CredentialsID != createdAPIKeyID {
+			t.Errorf("Expected the credentials '%s' to be created, got %+v", createdAPIKeyID, updates[0])
+		}
+		if updates[0].Credentials["apikey"] != "created-apikey" || updates[0].Credentials["account_id"] != "test-account-id" {
+			t.Errorf("Expected the created API key in the credentials, got %v", updates[0].Credentials)
+		}
+	})
+
+	t.Run("Delete credentials", func(t *testing.T) {
+		identityServer := newFakeIdentityServer(t, http.StatusCreated)
+		server, config := newEndToEndConfig(t, identityServer, sm.SecretTask_Type_DeleteCredentials, createdAPIKeyID)
+		client, err := NewSecretsManagerClient(config)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		err = RunTask(context.Background(), client, &config, NewApiKeyProvider())
+
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		expectedRequests := []string{"DELETE /v1/apikeys/" + createdAPIKeyID + "/lock", "DELETE /v1/apikeys/" + createdAPIKeyID}
+		if requests := identityServer.Requests(); !slices.Equal(requests, expectedRequests) {
+			t.Errorf("Expected the API key to be unlocked and deleted, got requests %q", requests)
+		}
+		if status := server.TaskStatus("test-secret-id", "test-secret-task-id"); status != sm.SecretTask_Status_CredentialsDeleted {
+			t.Errorf("Expected task status '%s', got '%s'", sm.SecretTask_Status_CredentialsDeleted, status)
+		}
+	})
+
+	t.Run("API key cannot be created", func(t *testing.T) {
+		identityServer := newFakeIdentityServer(t, http.StatusForbidden)
+		server, config := newEndToEndConfig(t, identityServer, sm.SecretTask_Type_CreateCredentials, "")
+		client, err := NewSecretsManagerClient(config)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		err = RunTask(context.Background(), client, &config, NewApiKeyProvider())
+
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+		updates := server.TaskUpdates()
+		if len(updates) != 1 {
+			t.Fatalf("Expected 1 task update, got %d", len(updates))
+		}
+		if updates[0].To != sm.SecretTask_Status_Failed || len(updates[0].Errors) != 1 || updates[0].Errors[0].Code != ErrApiKeyNotCreated {
+			t.Errorf("Expected the task to fail with code '%s', got %+v", ErrApiKeyNotCreated, updates[0])
+		}
+	})
+}
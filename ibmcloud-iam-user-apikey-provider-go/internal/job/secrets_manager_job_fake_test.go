@@ -0,0 +1,336 @@
+package job
+
+// Auto-generated by secrets-manager-job-generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
+)
+
+// FakeAPIKey is the only API key for which the IAM token service of FakeSecretsManagerServer issues access tokens
+const FakeAPIKey = "fake-apikey"
+
+// FakeEndpoint identifies an endpoint of FakeSecretsManagerServer
+type FakeEndpoint string
+
+// Endpoints of FakeSecretsManagerServer
+const (
+	FakeEndpointIAMToken          FakeEndpoint = "POST /identity/token"
+	FakeEndpointGetSecret         FakeEndpoint = "GET /api/v2/secrets/{id}"
+	FakeEndpointGetSecretTask     FakeEndpoint = "GET /api/v2/secrets/{secret_id}/tasks/{id}"
+	FakeEndpointReplaceSecretTask FakeEndpoint = "PUT /api/v2/secrets/{secret_id}/tasks/{id}"
+)
+
+// FakeFailure is a scripted failure of a request to FakeSecretsManagerServer
+type FakeFailure struct {
+	// StatusCode is the status code of the error response, e.g. 429 or 500. If it is 0, the request is served
+	// normally after Delay.
+	StatusCode int
+	// Header is added to the error response, e.g. Retry-After
+	Header http.Header
+	// Delay delays the response, e.g. beyond the deadline of the job to simulate a timeout. The response is
+	// abandoned when the client gives up.
+	Delay time.Duration
+}
+
+// FakeRequest is a request received by FakeSecretsManagerServer
+type FakeRequest struct {
+	Endpoint   FakeEndpoint
+	Path       string
+	StatusCode int // The status code of the response, 0 if the client gave up before the response
+}
+
+// FakeTaskUpdate is a state transition of a secret task of FakeSecretsManagerServer
+type FakeTaskUpdate struct {
+	SecretID      string
+	TaskID        string
+	From          string // The status before the update, empty for the first update of the task
+	To            string
+	CredentialsID string                    // The ID of the created credentials
+	Credentials   map[string]interface{}    // The payload of the created credentials
+	Errors        []MockSecretTaskErrorCall // The errors of a failed task
+}
+
+// finalTaskStatuses are the statuses after which Secrets Manager rejects the updates of a task
+var finalTaskStatuses = []string{
+	sm.SecretTask_Status_CredentialsCreated,
+	sm.SecretTask_Status_CredentialsDeleted,
+	sm.SecretTask_Status_Failed,
+}
+
+// FakeSecretsManagerServer is an in-process fake of the Secrets Manager v2 endpoints that the job uses and of the
+// IAM token service, for end-to-end tests of a provider with the real SDK client:
+//
+//	server := NewFakeSecretsManagerServer()
+//	defer server.Close()
+//	server.AddSecret("login-secret-id", NewMockArbitrarySecret("login-secret-id", "token"))
+//	server.Configure(&config)
+//	client, err := NewSecretsManagerClient(config)
+//	err = RunTask(ctx, client, &config, provider)
+//
+// It serves the secrets added with AddSecret, applies the task updates like Secrets Manager, i.e. it rejects the
+// updates of a task in a final state with 409, and records the requests and the task state transitions.
+// Secrets Manager requests need an access token issued for FakeAPIKey. Use FailNext to script failures.
+type FakeSecretsManagerServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	secrets      map[string]sm.SecretIntf
+	tasks        map[string]string // The status of the tasks, keyed by secret ID and task ID
+	failures     map[FakeEndpoint][]FakeFailure
+	accessTokens map[string]bool
+	requests     []FakeRequest
+	taskUpdates  []FakeTaskUpdate
+}
+
+// NewFakeSecretsManagerServer starts a FakeSecretsManagerServer without secrets. Close it at the end of the test.
+func NewFakeSecretsManagerServer() *FakeSecretsManagerServer {
+	s := &FakeSecretsManagerServer{
+		secrets:      make(map[string]sm.SecretIntf),
+		tasks:        make(map[string]string),
+		failures:     make(map[FakeEndpoint][]FakeFailure),
+		accessTokens: make(map[string]bool),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(string(FakeEndpointIAMToken), s.handle(FakeEndpointIAMToken, s.issueToken))
+	mux.HandleFunc(string(FakeEndpointGetSecret), s.handle(FakeEndpointGetSecret, s.getSecret))
+	mux.HandleFunc(string(FakeEndpointGetSecretTask), s.handle(FakeEndpointGetSecretTask, s.getSecretTask))
+	mux.HandleFunc(string(FakeEndpointReplaceSecretTask), s.handle(FakeEndpointReplaceSecretTask, s.replaceSecretTask))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Configure points config to the server, which acts as the Secrets Manager instance and the IAM token service,
+// and authenticates the job with FakeAPIKey
+func (s *FakeSecretsManagerServer) Configure(config *Config) {
+	config.SM_INSTANCE_URL = s.URL
+	config.SM_IAM_URL = s.URL
+	config.SM_AUTH_TYPE = core.AUTHTYPE_IAM
+	config.SM_ACCESS_APIKEY = FakeAPIKey
+}
+
+// AddSecret registers a secret that the server returns for the given ID, e.g. one created with NewMockArbitrarySecret,
+// NewMockCustomCredentialsSecret or NewMockServiceCredentialsSecret
+func (s *FakeSecretsManagerServer) AddSecret(id string, secret sm.SecretIntf) *FakeSecretsManagerServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[id] = secret
+	return s
+}
+
+// FailNext makes the next requests to endpoint fail in order, one failure per request
+func (s *FakeSecretsManagerServer) FailNext(endpoint FakeEndpoint, failures ...FakeFailure) *FakeSecretsManagerServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], failures...)
+	return s
+}
+
+// Requests returns the received requests in order
+func (s *FakeSecretsManagerServer) Requests() []FakeRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// RequestCount returns the number of requests received by endpoint
+func (s *FakeSecretsManagerServer) RequestCount(endpoint FakeEndpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, request := range s.requests {
+		if request.Endpoint == endpoint {
+			count++
+		}
+	}
+	return count
+}
+
+// TaskUpdates returns the accepted task updates in order
+func (s *FakeSecretsManagerServer) TaskUpdates() []FakeTaskUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.taskUpdates)
+}
+
+// TaskStatus returns the status of a task, or an empty string if it was not updated
+func (s *FakeSecretsManagerServer) TaskStatus(secretID, taskID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tasks[secretID+"/"+taskID]
+}
+
+// handle wraps the handler of an endpoint with the recording of the requests, the scripted failures and the
+// check of the access token. The handler returns the status code of its response.
+func (s *FakeSecretsManagerServer) handle(endpoint FakeEndpoint, handler func(w http.ResponseWriter, r *http.Request) int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statusCode := s.serve(endpoint, handler, w, r)
+		s.mu.Lock()
+		s.requests = append(s.requests, FakeRequest{Endpoint: endpoint, Path: r.URL.Path, StatusCode: statusCode})
+		s.mu.Unlock()
+	}
+}
+
+// serve answers a request with the next scripted failure of the endpoint, if any, or with its handler
+func (s *FakeSecretsManagerServer) serve(endpoint FakeEndpoint, handler func(w http.ResponseWriter, r *http.Request) int, w http.ResponseWriter, r *http.Request) int {
+	s.mu.Lock()
+	var failure FakeFailure
+	if failures := s.failures[endpoint]; len(failures) > 0 {
+		failure, s.failures[endpoint] = failures[0], failures[1:]
+	}
+	authorized := s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	s.mu.Unlock()
+
+	if failure.Delay > 0 {
+		// Read the body first, the server only notices that the client gave up once the request is read
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		select {
+		case <-time.After(failure.Delay):
+		case <-r.Context().Done():
+			return 0
+		}
+	}
+	if failure.StatusCode != 0 {
+		for key, values := range failure.Header {
+			w.Header()[key] = values
+		}
+		return writeFakeError(w, failure.StatusCode, "scripted failure")
+	}
+	if endpoint != FakeEndpointIAMToken && !authorized {
+		return writeFakeError(w, http.StatusUnauthorized, "the access token is missing or was not issued by the IAM token service of the server")
+	}
+	return handler(w, r)
+}
+
+func (s *FakeSecretsManagerServer) issueToken(w http.ResponseWriter, r *http.Request) int {
+	switch r.FormValue("grant_type") {
+	case "urn:ibm:params:oauth:grant-type:apikey":
+		if r.FormValue("apikey") != FakeAPIKey {
+			return writeFakeError(w, http.StatusBadRequest, "provided API key could not be found")
+		}
+	case "urn:ibm:params:oauth:grant-type:cr-token":
+	default:
+		return writeFakeError(w, http.StatusBadRequest, "unsupported grant type")
+	}
+
+	s.mu.Lock()
+	accessToken := fmt.Sprintf("fake-access-token-%d", len(s.accessTokens)+1)
+	s.accessTokens[accessToken] = true
+	s.mu.Unlock()
+
+	now := time.Now()
+	return writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"expiration":   now.Add(time.Hour).Unix(),
+	})
+}
+
+func (s *FakeSecretsManagerServer) getSecret(w http.ResponseWriter, r *http.Request) int {
+	s.mu.Lock()
+	secret, ok := s.secrets[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		return writeFakeError(w, http.StatusNotFound, fmt.Sprintf("secret with ID '%s' not found", r.PathValue("id")))
+	}
+	return writeFakeJSON(w, http.StatusOK, secret)
+}
+
+func (s *FakeSecretsManagerServer) getSecretTask(w http.ResponseWriter, r *http.Request) int {
+	s.mu.Lock()
+	status, ok := s.tasks[r.PathValue("secret_id")+"/"+r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		return writeFakeError(w, http.StatusNotFound, fmt.Sprintf("secret task with ID '%s' not found", r.PathValue("id")))
+	}
+	return writeFakeJSON(w, http.StatusOK, fakeSecretTask(r.PathValue("secret_id"), r.PathValue("id"), status))
+}
+
+func (s *FakeSecretsManagerServer) replaceSecretTask(w http.ResponseWriter, r *http.Request) int {
+	var body struct {
+		Status      string `json:"status"`
+		Credentials *struct {
+			ID      string                 `json:"id"`
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"credentials"`
+		Errors []struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return writeFakeError(w, http.StatusBadRequest, fmt.Sprintf("invalid secret task update: %s", err))
+	}
+	if !slices.Contains(finalTaskStatuses, body.Status) {
+		return writeFakeError(w, http.StatusBadRequest, fmt.Sprintf("invalid secret task status '%s'", body.Status))
+	}
+
+	update := FakeTaskUpdate{SecretID: r.PathValue("secret_id"), TaskID: r.PathValue("id"), To: body.Status}
+	if body.Credentials != nil {
+		update.CredentialsID = body.Credentials.ID
+		update.Credentials = body.Credentials.Payload
+	}
+	for _, taskError := range body.Errors {
+		update.Errors = append(update.Errors, MockSecretTaskErrorCall{Code: taskError.Code, Description: taskError.Description})
+	}
+
+	s.mu.Lock()
+	key := update.SecretID + "/" + update.TaskID
+	update.From = s.tasks[key]
+	if slices.Contains(finalTaskStatuses, update.From) {
+		s.mu.Unlock()
+		return writeFakeError(w, http.StatusConflict, fmt.Sprintf("the secret task is already in a final state: %s", update.From))
+	}
+	s.tasks[key] = update.To
+	s.taskUpdates = append(s.taskUpdates, update)
+	s.mu.Unlock()
+
+	return writeFakeJSON(w, http.StatusOK, fakeSecretTask(update.SecretID, update.TaskID, update.To))
+}
+
+// fakeSecretTask returns the JSON representation of a secret task with the given status
+func fakeSecretTask(secretID, taskID, status string) map[string]interface{} {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return map[string]interface{}{
+		"id":               taskID,
+		"secret_id":        secretID,
+		"status":           status,
+		"created_by":       "fake",
+		"updated_by":       "fake",
+		"creation_date":    now,
+		"last_update_date": now,
+	}
+}
+
+// writeFakeJSON writes a JSON response and returns its status code
+func writeFakeJSON(w http.ResponseWriter, statusCode int, body interface{}) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+	return statusCode
+}
+
+// writeFakeError writes an error response in the format of the Secrets Manager API and returns its status code
+func writeFakeError(w http.ResponseWriter, statusCode int, message string) int {
+	apiError := map[string]string{
+		"code":    strings.ToLower(strings.ReplaceAll(http.StatusText(statusCode), " ", "_")),
+		"message": message,
+	}
+	return writeFakeJSON(w, statusCode, map[string]interface{}{
+		"errors":      []map[string]string{apiError},
+		"status_code": statusCode,
+	})
+}
@@ -1,16 +1,12 @@
 package job
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
 	resty "github.com/go-resty/resty/v2"
-	"jfrog-access-token-provider-go/internal/utils"
-	"log"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 )
 
@@ -39,109 +35,48 @@ type JFrogErrorResponseBody struct {
 	} `json:"errors"`
 }
 
-var logger *utils.Logger
-
-func Run() {
-
-	//start
-	config, err := ConfigFromEnv()
-	if err != nil {
-		log.Fatalf("Failed to create config: %v", err)
-	}
-	config.SM_JFROG_BASE_URL = strings.TrimSuffix(config.SM_JFROG_BASE_URL, "/")
-
-	smClient, err := NewSecretsManagerClient(config)
-	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
-	}
-
-	restyClient := utils.RestyClientStruct{
-		Client: resty.New().
-			SetRetryCount(RETRY_COUNT).
-			SetRetryWaitTime(RETRY_MIN_WAIT_TIME_SECONDS * time.Second).
-			SetRetryMaxWaitTime(RETRY_MAX_WAIT_TIME_SECONDS * time.Second).
-			AddRetryCondition(
-				func(r *resty.Response, err error) bool {
-					return err != nil || r.StatusCode() >= http.StatusTooManyRequests
-				},
-			)}
-
-	logger = utils.NewLogger(config.SM_SECRET_TASK_ID, config.SM_ACTION)
-
-	switch config.SM_ACTION {
-	case sm.SecretTask_Type_CreateCredentials:
-		generateCredentials(smClient, &restyClient, &config)
-	case sm.SecretTask_Type_DeleteCredentials:
-		deleteCredentials(smClient, &restyClient, &config)
-
-	default:
-		updateTaskAboutErrorAndExit(smClient, &config, Err10000, fmt.Sprintf("unknown action: '%s'", config.SM_ACTION))
-	}
-
+// AccessTokenProvider creates and revokes JFrog access tokens
+type AccessTokenProvider struct {
+	restyClient RestyClientIntf
 }
 
-// generateCredentials generates the credentials for the given secret
-func generateCredentials(smClient SecretsManagerClient, restyClient utils.RestyClientIntf, config *Config) {
-	// Set default values for non required config variables if not set by the user
-	setDefaultValues(config)
-
-	// Create JFrog Access Token
-	accessToken, tokenId, err := createJFrogAccessToken(smClient, restyClient, config)
-	if err != nil {
-		logger.Error(fmt.Errorf("error generating credentials: %s", err.Error()))
-		updateTaskAboutErrorAndExit(smClient, config, Err10001, fmt.Sprintf("error: %s", err.Error()))
-	}
-
-	// Set the token ID as the credentials ID
-	config.SM_CREDENTIALS_ID = tokenId
-
-	// Create credentials payload
-	credentialsPayload := CredentialsPayload{
-		ACCESS_TOKEN: accessToken,
-	}
-
-	// Update task about certificate created
-	result, err := UpdateTaskAboutCredentialsCreated(smClient, config, credentialsPayload)
-	if err != nil {
-		var errBuilder strings.Builder
-		errBuilder.WriteString(fmt.Sprintf("cannot update task: %s", err.Error()))
-		err = revokeJFrogAccessToken(smClient, restyClient, config)
-		if err != nil {
-			errBuilder.WriteString(fmt.Sprintf("cannot revoke the JFrog access token with token id: '%s'. error: %s", config.SM_CREDENTIALS_ID, err.Error()))
-		} else {
-			errBuilder.WriteString(fmt.Sprintf("JFrog access token with token id: '%s' was revoked. ", config.SM_CREDENTIALS_ID))
-		}
-		logger.Error(errors.New(errBuilder.String()))
-		os.Exit(1)
+// NewAccessTokenProvider creates an AccessTokenProvider with a resty client that retries failed requests
+func NewAccessTokenProvider() *AccessTokenProvider {
+	return &AccessTokenProvider{
+		restyClient: &RestyClientStruct{
+			Client: resty.New().
+				SetRetryCount(RETRY_COUNT).
+				SetRetryWaitTime(RETRY_MIN_WAIT_TIME_SECONDS * time.Second).
+				SetRetryMaxWaitTime(RETRY_MAX_WAIT_TIME_SECONDS * time.Second).
+				AddRetryCondition(
+					func(r *resty.Response, err error) bool {
+						return err != nil || r.StatusCode() >= http.StatusTooManyRequests
+					},
+				)},
 	}
-
-	logger.Info(fmt.Sprintf("task successfully updated: JFrog access token with token id: '%s' was created by: %s ", config.SM_CREDENTIALS_ID, *result.UpdatedBy))
-
 }
 
-// deleteCredentials deletes the credentials identified by the credentials' id for the given secret
-func deleteCredentials(smClient SecretsManagerClient, restyClient utils.RestyClientIntf, config *Config) {
-	err := revokeJFrogAccessToken(smClient, restyClient, config)
-	if err != nil {
-		logger.Error(fmt.Errorf("error revoking credentials: %s", err.Error()))
-		updateTaskAboutErrorAndExit(smClient, config, Err10002, fmt.Sprintf("error revoking credentials with credentials id: '%s': %s", config.SM_CREDENTIALS_ID, err.Error()))
-	}
-
-	result, err := UpdateTaskAboutCredentialsDeleted(smClient, config)
+// Create creates a JFrog access token and returns it with its token ID as the credentials ID
+func (p *AccessTokenProvider) Create(ctx context.Context, smClient SecretsManagerClient, config *Config) (CredentialsPayload, string, error) {
+	accessToken, tokenId, err := createJFrogAccessToken(ctx, smClient, p.restyClient, config)
 	if err != nil {
-		logger.Error(fmt.Errorf("cannot update task about revoked credentials with credentials id: '%s'. error: %s. ", config.SM_CREDENTIALS_ID, err.Error()))
-		os.Exit(1)
+		return CredentialsPayload{}, "", err
 	}
+	return CredentialsPayload{ACCESS_TOKEN: accessToken}, tokenId, nil
+}
 
-	logger.Info(fmt.Sprintf("task successfully updated: credentials with credentials id: '%s' was revoked by: %s ", config.SM_CREDENTIALS_ID, *result.UpdatedBy))
-
+// Delete revokes the JFrog access token identified by config.SM_CREDENTIALS_ID
+func (p *AccessTokenProvider) Delete(ctx context.Context, smClient SecretsManagerClient, config *Config) error {
+	return NewAccessTokenNotRevokedError(revokeJFrogAccessToken(ctx, smClient, p.restyClient, config))
 }
 
 // createJFrogAccessToken creates JFrog Access Token
-func createJFrogAccessToken(smClient SecretsManagerClient, restyClient utils.RestyClientIntf, config *Config) (string, string, error) {
-	jfrogLoginToken, err := fetchJFrogServiceCredentials(smClient, config)
+// The errors carry the ErrAccessTokenNotCreated code, or the ErrAccessTokenResponseInvalid code if the response of
+// JFrog does not contain the access token and its ID.
+func createJFrogAccessToken(ctx context.Context, smClient SecretsManagerClient, restyClient RestyClientIntf, config *Config) (string, string, error) {
+	jfrogLoginToken, err := fetchJFrogServiceCredentials(ctx, smClient, config)
 	if err != nil {
-		return "", "", err
+		return "", "", NewAccessTokenNotCreatedError(err)
 	}
 
 	createAccessTokenRequestBody := CreateAccessTokenRequestBody{
@@ -154,64 +89,52 @@ func createJFrogAccessToken(smClient SecretsManagerClient, restyClient utils.Res
 		IncludeReferenceToken: config.SM_INCLUDE_REFERENCE_TOKEN,
 	}
 
-	resp, err := restyClient.Post(jfrogLoginToken, createAccessTokenRequestBody, config.SM_JFROG_BASE_URL+TOKENS_PATH)
+	resp, err := restyClient.Post(ctx, jfrogLoginToken, createAccessTokenRequestBody, config.SM_JFROG_BASE_URL.JoinPath(TOKENS_PATH).String())
 	if err != nil {
-		return "", "", fmt.Errorf("client returned an error: %s", err.Error())
+		return "", "", NewAccessTokenNotCreatedError(fmt.Errorf("client returned an error: %s", err.Error()))
 	}
 	if resp.IsError() {
 		message := extractErrorMessageFromJFrogErrorResponse(resp)
-		return "", "", fmt.Errorf("JFrog returned an error: Status: %s. Error: %s", resp.Status(), message)
+		return "", "", NewAccessTokenNotCreatedError(fmt.Errorf("JFrog returned an error: Status: %s. Error: %s", resp.Status(), message))
 	}
 
 	var tokenData map[string]interface{}
 	err = json.Unmarshal(resp.Body(), &tokenData)
 	if err != nil {
-		return "", "", fmt.Errorf("error unmarshaling token data: %s", err.Error())
+		return "", "", NewAccessTokenResponseInvalidError(fmt.Errorf("error unmarshaling token data: %s", err.Error()))
+	}
+	accessToken, ok := tokenData["access_token"].(string)
+	if !ok {
+		return "", "", NewAccessTokenResponseInvalidError(errors.New("the access_token field is missing or is not a string"))
+	}
+	tokenId, ok := tokenData["token_id"].(string)
+	if !ok {
+		return "", "", NewAccessTokenResponseInvalidError(errors.New("the token_id field is missing or is not a string"))
 	}
-	accessToken := tokenData["access_token"].(string)
-	tokenId := tokenData["token_id"].(string)
 
-	logger.Info(fmt.Sprintf("Access Token successfully created. Credentials ID: %s", tokenId))
+	LoggerFromContext(ctx).Info("access token successfully created", "token_id", tokenId)
 
 	return accessToken, tokenId, nil
 }
 
 // fetchJFrogServiceCredentials fetches the credentials for JFrog from Secrets Manager
-func fetchJFrogServiceCredentials(smClient SecretsManagerClient, config *Config) (string, error) {
-	secret, err := GetSecret(smClient, config.SM_LOGIN_SECRET_ID)
+func fetchJFrogServiceCredentials(ctx context.Context, smClient SecretsManagerClient, config *Config) (string, error) {
+	secret, err := ResolveLoginSecretId(ctx, smClient, config)
 	if err != nil {
-		if strings.Contains(err.Error(), "Provided API key could not be found") {
-			logger.Error(fmt.Errorf("cannot call the secrets manager service: %v", err))
-			os.Exit(1)
-		}
 		return "", err
 	}
-
-	switch v := secret.(type) {
-	case *sm.ArbitrarySecret:
-		logger.Info(fmt.Sprintf("Arbitrary secret with ID: %s succesfully obtained.", config.SM_LOGIN_SECRET_ID))
-		return *v.Payload, nil
-	case *sm.CustomCredentialsSecret:
-		logger.Info(fmt.Sprintf("Custom Credentials secret with ID: %s succesfully obtained.", config.SM_LOGIN_SECRET_ID))
-		credentials := v.CredentialsContent
-		accessToken, ok := credentials["access_token"]
-		if ok {
-			return fmt.Sprintf("%v", accessToken), nil
-		}
-		return "", fmt.Errorf("secret '%s' is missing 'access_token' field", config.SM_LOGIN_SECRET_ID)
-	default:
-		return "", fmt.Errorf("get secret id: '%s' returned unexpected secret type: %T, expected arbitrary or custom credentials type", config.SM_LOGIN_SECRET_ID, secret)
-	}
+	LoggerFromContext(ctx).Info("secret successfully obtained", "secret_type", secret.Type, "login_secret_id", secret.ID)
+	return secret.Value, nil
 }
 
 // revokeJFrogAccessToken revokes JFrog access token with a given token ID
-func revokeJFrogAccessToken(smClient SecretsManagerClient, restyClient utils.RestyClientIntf, config *Config) error {
-	jfrogLoginToken, err := fetchJFrogServiceCredentials(smClient, config)
+func revokeJFrogAccessToken(ctx context.Context, smClient SecretsManagerClient, restyClient RestyClientIntf, config *Config) error {
+	jfrogLoginToken, err := fetchJFrogServiceCredentials(ctx, smClient, config)
 	if err != nil {
 		return err
 	}
 
-	resp, err := restyClient.Delete(jfrogLoginToken, config.SM_JFROG_BASE_URL+TOKENS_PATH+config.SM_CREDENTIALS_ID)
+	resp, err := restyClient.Delete(ctx, jfrogLoginToken, config.SM_JFROG_BASE_URL.JoinPath(TOKENS_PATH, config.SM_CREDENTIALS_ID).String())
 
 	if err != nil {
 		err = fmt.Errorf("Resty client returned an error: %s", err.Error())
@@ -223,35 +146,11 @@ func revokeJFrogAccessToken(smClient SecretsManagerClient, restyClient utils.Res
 		return err
 	}
 
-	logger.Info(fmt.Sprintf("Token: %s is successfully revoked", config.SM_CREDENTIALS_ID))
+	LoggerFromContext(ctx).Info("access token successfully revoked")
 
 	return nil
 }
 
-// UpdateTaskAboutError updates the task with the given task id with the given error code and description
-func updateTaskAboutErrorAndExit(smClient SecretsManagerClient, config *Config, code, description string) {
-	result, err := UpdateTaskAboutError(smClient, config, code, description)
-	if err != nil {
-		logger.Error(fmt.Errorf("cannot update task about error with code: '%s' and description: '%s'. returned error: %w", code, description, err))
-	} else {
-		logger.Info(fmt.Sprintf("updated task about error with code: '%s' and description: '%s'. task updated. by: %s", code, description, *result.UpdatedBy))
-	}
-	os.Exit(1)
-}
-
-// setDefaultValues sets default values for non required config variables if not set by the user
-func setDefaultValues(config *Config) {
-	if config.SM_SCOPE == "" {
-		config.SM_SCOPE = "applied-permissions/user"
-	}
-	if config.SM_EXPIRES_IN_SECONDS == 0 {
-		config.SM_EXPIRES_IN_SECONDS = 7776000 // 90 days
-	}
-	if config.SM_AUDIENCE == "" {
-		config.SM_AUDIENCE = "*@*"
-	}
-}
-
 // extractErrorMessageFromJFrogErrorResponse extracts the error message from the JFrog error response
 func extractErrorMessageFromJFrogErrorResponse(resp *resty.Response) string {
 	var responseBody JFrogErrorResponseBody
@@ -1,14 +1,14 @@
 package job
 
 import (
+	"context"
 	"fmt"
-	"github.com/IBM/go-sdk-core/v5/core"
 	sm "github.com/IBM/secrets-manager-go-sdk/v2/secretsmanagerv2"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"jfrog-access-token-provider-go/internal/utils"
 	"net/http"
+	"net/url"
 	"testing"
 )
 
@@ -17,119 +17,52 @@ const (
 	JFrogValidTokenId     = "jfrog-valid-token-id"
 )
 
-// MockSecretsManagerClient is a mock implementation of SecretsManagerClient
-type MockSecretsManagerClient struct {
-	mock.Mock
-}
-
-func (m *MockSecretsManagerClient) UpdateTaskAboutError(config Config, code, description string) (*sm.SecretTask, error) {
-	args := m.Called(config, code, description)
-	return args.Get(0).(*sm.SecretTask), args.Error(1)
-}
-
-func (m *MockSecretsManagerClient) UpdateTaskAboutCredentialsCreated(config Config, payload CredentialsPayload) (*sm.SecretTask, error) {
-	args := m.Called(config, payload)
-	return args.Get(0).(*sm.SecretTask), args.Error(1)
-}
-
-func (m *MockSecretsManagerClient) UpdateTaskAboutCredentialsDeleted(config Config) (*sm.SecretTask, error) {
-	args := m.Called(config)
-	return args.Get(0).(*sm.SecretTask), args.Error(1)
-}
-
-// Mock implementation of GetSecret
-func (m *MockSecretsManagerClient) GetSecret(options *sm.GetSecretOptions) (sm.SecretIntf, *core.DetailedResponse, error) {
-	args := m.Called(options)
-
-	var secret sm.SecretIntf
-	if args.Get(0) != nil {
-		secret = args.Get(0).(sm.SecretIntf)
-	}
-	var response *core.DetailedResponse
-	if args.Get(1) != nil {
-		response = args.Get(1).(*core.DetailedResponse)
-	}
-	return secret, response, args.Error(2)
-}
-
-// Mock implementation of ReplaceSecretTask
-func (m *MockSecretsManagerClient) ReplaceSecretTask(
-	options *sm.ReplaceSecretTaskOptions,
-) (*sm.SecretTask, *core.DetailedResponse, error) {
-	args := m.Called(options)
-
-	var task *sm.SecretTask
-	if args.Get(0) != nil {
-		task = args.Get(0).(*sm.SecretTask)
-	}
-
-	var response *core.DetailedResponse
-	if args.Get(1) != nil {
-		response = args.Get(1).(*core.DetailedResponse)
-	}
-
-	var err error
-	if args.Get(2) != nil {
-		err = args.Error(2)
-	}
-
-	return task, response, err
-}
-
-// Mock implementation of NewSecretTaskError
-func (m *MockSecretsManagerClient) NewSecretTaskError(code, description string) (*sm.SecretTaskError, error) {
-	args := m.Called(code, description)
-
-	var taskError *sm.SecretTaskError
-	if args.Get(0) != nil {
-		taskError = args.Get(0).(*sm.SecretTaskError)
-	}
-	return taskError, args.Error(1)
-}
-
-// Mock implementation of NewCustomCredentialsNewCredentials
-func (m *MockSecretsManagerClient) NewCustomCredentialsNewCredentials(id string, credentials map[string]interface{}) (*sm.CustomCredentialsNewCredentials, error) {
-	args := m.Called(id, credentials)
-
-	var customCredentials *sm.CustomCredentialsNewCredentials
-	if args.Get(0) != nil {
-		customCredentials = args.Get(0).(*sm.CustomCredentialsNewCredentials)
-	}
-	return customCredentials, args.Error(1)
-}
-
 // MockRestyClient is a mock implementation of RestyClient
 type MockRestyClient struct {
 	mock.Mock
 }
 
-func (m *MockRestyClient) Post(authToken string, body interface{}, url string) (*resty.Response, error) {
+func (m *MockRestyClient) Post(ctx context.Context, authToken string, body interface{}, url string) (*resty.Response, error) {
 	args := m.Called(authToken, body, url)
 	return args.Get(0).(*resty.Response), args.Error(1)
 }
 
-func (m *MockRestyClient) Delete(authToken string, url string) (*resty.Response, error) {
+func (m *MockRestyClient) Delete(ctx context.Context, authToken string, url string) (*resty.Response, error) {
 	args := m.Called(authToken, url)
 	return args.Get(0).(*resty.Response), args.Error(1)
 }
 
-// TestSetDefaultValues tests the setDefaultValues function
-func TestSetDefaultValues(t *testing.T) {
+// setCommonEnv sets the environment variables that Secrets Manager passes to every job run
+func setCommonEnv(t *testing.T) {
+	t.Setenv("SM_ACCESS_APIKEY", "test-apikey")
+	t.Setenv("SM_INSTANCE_URL", "https://test-instance.us-south.secrets-manager.appdomain.cloud")
+	t.Setenv("SM_SECRET_GROUP_ID", "test-secret-group-id")
+	t.Setenv("SM_SECRET_NAME", "test-secret-name")
+	t.Setenv("SM_SECRET_TASK_ID", "test-secret-task-id")
+	t.Setenv("SM_SECRET_ID", "test-secret-id")
+	t.Setenv("SM_ACTION", sm.SecretTask_Type_CreateCredentials)
+	t.Setenv("SM_TRIGGER", "secret_creation")
+	t.Setenv("SM_LOGIN_SECRET_ID_VALUE", "login-secret-id")
+	t.Setenv("SM_JFROG_BASE_URL_VALUE", "https://jfrog.example.com")
+}
+
+// TestConfigFromEnvDefaults tests that the default values declared in job_config.json are applied
+func TestConfigFromEnvDefaults(t *testing.T) {
 	testCases := []struct {
 		name           string
-		inputConfig    Config
+		env            map[string]string
 		expectedConfig Config
 	}{
 		{
 			name: "All values set",
-			inputConfig: Config{
-				SM_USERNAME:                "username",
-				SM_SCOPE:                   "scope",
-				SM_EXPIRES_IN_SECONDS:      3600,
-				SM_REFRESHABLE:             true,
-				SM_DESCRIPTION:             "description",
-				SM_AUDIENCE:                "audience",
-				SM_INCLUDE_REFERENCE_TOKEN: false,
+			env: map[string]string{
+				"SM_USERNAME_VALUE":                "username",
+				"SM_SCOPE_VALUE":                   "scope",
+				"SM_EXPIRES_IN_SECONDS_VALUE":      "3600",
+				"SM_REFRESHABLE_VALUE":             "true",
+				"SM_DESCRIPTION_VALUE":             "description",
+				"SM_AUDIENCE_VALUE":                "audience",
+				"SM_INCLUDE_REFERENCE_TOKEN_VALUE": "false",
 			},
 			expectedConfig: Config{
 				SM_USERNAME:                "username",
@@ -142,8 +75,8 @@ func TestSetDefaultValues(t *testing.T) {
 			},
 		},
 		{
-			name:        "No values set",
-			inputConfig: Config{},
+			name: "No values set",
+			env:  map[string]string{},
 			expectedConfig: Config{
 				SM_SCOPE:                   "applied-permissions/user",
 				SM_EXPIRES_IN_SECONDS:      7776000,
@@ -156,41 +89,88 @@ func TestSetDefaultValues(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			setDefaultValues(&tc.inputConfig)
-			assert.Equal(t, tc.expectedConfig, tc.inputConfig)
+			setCommonEnv(t)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := ConfigFromEnv()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConfig.SM_USERNAME, config.SM_USERNAME)
+			assert.Equal(t, tc.expectedConfig.SM_SCOPE, config.SM_SCOPE)
+			assert.Equal(t, tc.expectedConfig.SM_EXPIRES_IN_SECONDS, config.SM_EXPIRES_IN_SECONDS)
+			assert.Equal(t, tc.expectedConfig.SM_REFRESHABLE, config.SM_REFRESHABLE)
+			assert.Equal(t, tc.expectedConfig.SM_DESCRIPTION, config.SM_DESCRIPTION)
+			assert.Equal(t, tc.expectedConfig.SM_AUDIENCE, config.SM_AUDIENCE)
+			assert.Equal(t, tc.expectedConfig.SM_INCLUDE_REFERENCE_TOKEN, config.SM_INCLUDE_REFERENCE_TOKEN)
 		})
 	}
 }
 
-// TestCreateJFrogAccessToken tests the createJFrogAccessToken function
-func TestCreateJFrogAccessToken(t *testing.T) {
-	JFrogServiceCredentialsSecretBearerToken := "jfrog-bearer-token"
+// TestFetchJFrogServiceCredentials tests that the login secret is resolved with the declared secret types and field
+func TestFetchJFrogServiceCredentials(t *testing.T) {
 	loginSecretId := "login-secret-id"
 
-	// Create a mock logger
-	mockLogger := utils.NewLogger("secret-task-id", "create-jfrog-access-token")
+	testCases := []struct {
+		name          string
+		secret        sm.SecretIntf
+		expectedToken string
+		expectedCode  string
+	}{
+		{
+			name:          "Arbitrary secret",
+			secret:        NewMockArbitrarySecret(loginSecretId, "arbitrary-token"),
+			expectedToken: "arbitrary-token",
+		},
+		{
+			name:          "Custom credentials secret",
+			secret:        NewMockCustomCredentialsSecret(loginSecretId, map[string]interface{}{"access_token": "custom-token"}),
+			expectedToken: "custom-token",
+		},
+		{
+			name:         "Custom credentials secret without access_token",
+			secret:       NewMockCustomCredentialsSecret(loginSecretId, map[string]interface{}{"token": "custom-token"}),
+			expectedCode: ErrSecretFieldMissing,
+		},
+		{
+			name:         "Service credentials secret",
+			secret:       NewMockServiceCredentialsSecret(loginSecretId, map[string]interface{}{"access_token": "service-token"}),
+			expectedCode: ErrSecretTypeNotAllowed,
+		},
+	}
 
-	// Store the original logger and restore it after the test
-	originalLogger := logger
-	defer func() { logger = originalLogger }()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSMClient := NewMockSecretsManagerClient().AddSecret(loginSecretId, tc.secret)
+			config := Config{SM_LOGIN_SECRET_ID: loginSecretId}
+
+			token, err := fetchJFrogServiceCredentials(context.Background(), mockSMClient, &config)
+
+			if tc.expectedCode != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedCode, ErrorCode(err, ErrAccessTokenNotCreated))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedToken, token)
+		})
+	}
+}
 
-	// Set the global logger to our mock logger
-	logger = mockLogger
+// TestCreateJFrogAccessToken tests the createJFrogAccessToken function
+func TestCreateJFrogAccessToken(t *testing.T) {
+	JFrogServiceCredentialsSecretBearerToken := "jfrog-bearer-token"
+	loginSecretId := "login-secret-id"
 
 	// Create a mock IBM Cloud Secrets Manager client
-	mockSMClient := new(MockSecretsManagerClient)
-	mockSMClient.On("GetSecret", mock.Anything).
-		Return(&sm.ArbitrarySecret{
-			Payload: &JFrogServiceCredentialsSecretBearerToken,
-			ID:      &loginSecretId,
-		},
-			&core.DetailedResponse{
-				StatusCode: http.StatusOK,
-			},
-			nil)
+	mockSMClient := NewMockSecretsManagerClient().
+		AddSecret(loginSecretId, NewMockArbitrarySecret(loginSecretId, JFrogServiceCredentialsSecretBearerToken))
 
 	// Create a mock config
-	mockConfig := Config{}
+	mockConfig := Config{
+		SM_LOGIN_SECRET_ID: loginSecretId,
+		SM_JFROG_BASE_URL:  &url.URL{Scheme: "https", Host: "jfrog.example.com", Path: "/"},
+	}
 
 	// Create a mock Resty client
 	mockRestyClient := new(MockRestyClient)
@@ -201,10 +181,10 @@ func TestCreateJFrogAccessToken(t *testing.T) {
 	}
 	resp.SetBody([]byte(fmt.Sprintf(`{"access_token": "%s", "token_id": "%s"}`, JFrogValidAccessToken, JFrogValidTokenId)))
 
-	mockRestyClient.On("Post", mock.Anything, mock.Anything, mock.Anything).
+	mockRestyClient.On("Post", mock.Anything, mock.Anything, "https://jfrog.example.com/access/api/v1/tokens/").
 		Return(&resp, nil)
 
-	accessToken, tokenId, err := createJFrogAccessToken(mockSMClient, mockRestyClient, &mockConfig)
+	accessToken, tokenId, err := createJFrogAccessToken(context.Background(), mockSMClient, mockRestyClient, &mockConfig)
 
 	// Validate access token
 	assert.Equal(t, JFrogValidAccessToken, accessToken)
@@ -212,36 +192,70 @@ func TestCreateJFrogAccessToken(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// TestCreateJFrogAccessTokenInvalidResponse tests the error code of a JFrog response without the access token or its ID
+func TestCreateJFrogAccessTokenInvalidResponse(t *testing.T) {
+	loginSecretId := "login-secret-id"
+	testCases := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "Response that is not JSON",
+			body:          `<html></html>`,
+			expectedError: "error unmarshaling token data",
+		},
+		{
+			name:          "Missing access token",
+			body:          fmt.Sprintf(`{"token_id": "%s"}`, JFrogValidTokenId),
+			expectedError: "the access_token field is missing or is not a string",
+		},
+		{
+			name:          "Token ID that is not a string",
+			body:          fmt.Sprintf(`{"access_token": "%s", "token_id": 42}`, JFrogValidAccessToken),
+			expectedError: "the token_id field is missing or is not a string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSMClient := NewMockSecretsManagerClient().
+				AddSecret(loginSecretId, NewMockArbitrarySecret(loginSecretId, "jfrog-bearer-token"))
+			mockConfig := Config{
+				SM_LOGIN_SECRET_ID: loginSecretId,
+				SM_JFROG_BASE_URL:  &url.URL{Scheme: "https", Host: "jfrog.example.com", Path: "/"},
+			}
+			mockRestyClient := new(MockRestyClient)
+			resp := resty.Response{
+				RawResponse: &http.Response{
+					StatusCode: http.StatusOK,
+				},
+			}
+			resp.SetBody([]byte(tc.body))
+			mockRestyClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(&resp, nil)
+
+			_, _, err := createJFrogAccessToken(context.Background(), mockSMClient, mockRestyClient, &mockConfig)
+
+			assert.ErrorContains(t, err, tc.expectedError)
+			assert.Equal(t, ErrAccessTokenResponseInvalid, ErrorCode(err, ""))
+		})
+	}
+}
+
 // TestRevokeJFrogAccessToken tests the revokeJFrogAccessToken function
 func TestRevokeJFrogAccessToken(t *testing.T) {
 	JFrogServiceCredentialsSecretBearerToken := "jfrog-bearer-token"
 	loginSecretId := "login-secret-id"
 
-	// Create a mock logger
-	mockLogger := utils.NewLogger("secret-task-id", "revoke-jfrog-access-token")
-
-	// Store the original logger and restore it after the test
-	originalLogger := logger
-	defer func() { logger = originalLogger }()
-
-	// Set the global logger to our mock logger
-	logger = mockLogger
-
 	// Create a mock IBM Cloud Secrets Manager client
-	mockSMClient := new(MockSecretsManagerClient)
-	mockSMClient.On("GetSecret", mock.Anything).
-		Return(&sm.ArbitrarySecret{
-			Payload: &JFrogServiceCredentialsSecretBearerToken,
-			ID:      &loginSecretId,
-		},
-			&core.DetailedResponse{
-				StatusCode: http.StatusOK,
-			},
-			nil)
+	mockSMClient := NewMockSecretsManagerClient().
+		AddSecret(loginSecretId, NewMockArbitrarySecret(loginSecretId, JFrogServiceCredentialsSecretBearerToken))
 
 	// Create a mock config
 	mockConfig := Config{
-		SM_CREDENTIALS_ID: JFrogValidTokenId,
+		SM_CREDENTIALS_ID:  JFrogValidTokenId,
+		SM_LOGIN_SECRET_ID: loginSecretId,
+		SM_JFROG_BASE_URL:  &url.URL{Scheme: "https", Host: "jfrog.example.com", Path: "/"},
 	}
 
 	// Create a mock Resty client
@@ -252,11 +266,92 @@ func TestRevokeJFrogAccessToken(t *testing.T) {
 		},
 	}
 
-	mockRestyClient.On("Delete", mock.Anything, mock.Anything).
+	mockRestyClient.On("Delete", mock.Anything, "https://jfrog.example.com/access/api/v1/tokens/"+JFrogValidTokenId).
 		Return(&resp, nil)
 
-	err := revokeJFrogAccessToken(mockSMClient, mockRestyClient, &mockConfig)
+	err := revokeJFrogAccessToken(context.Background(), mockSMClient, mockRestyClient, &mockConfig)
 
 	// Validate no error
 	assert.Nil(t, err)
 }
+
+// TestRunTaskErrorCodes tests the error codes reported to Secrets Manager when the access token provider fails
+func TestRunTaskErrorCodes(t *testing.T) {
+	loginSecretId := "login-secret-id"
+
+	t.Run("Login secret without access_token", func(t *testing.T) {
+		mockSMClient := NewMockSecretsManagerClient().
+			AddSecret(loginSecretId, NewMockCustomCredentialsSecret(loginSecretId, map[string]interface{}{"token": "custom-token"}))
+		config := Config{SM_ACTION: sm.SecretTask_Type_CreateCredentials, SM_LOGIN_SECRET_ID: loginSecretId}
+		provider := &AccessTokenProvider{restyClient: new(MockRestyClient)}
+
+		err := RunTask(context.Background(), mockSMClient, &config, provider)
+
+		assert.Error(t, err)
+		mockSMClient.AssertTaskFailed(t, ErrSecretFieldMissing)
+	})
+
+	t.Run("Token cannot be revoked", func(t *testing.T) {
+		mockSMClient := NewMockSecretsManagerClient().
+			AddSecret(loginSecretId, NewMockArbitrarySecret(loginSecretId, "jfrog-bearer-token"))
+		config := Config{
+			SM_ACTION:          sm.SecretTask_Type_DeleteCredentials,
+			SM_CREDENTIALS_ID:  JFrogValidTokenId,
+			SM_LOGIN_SECRET_ID: loginSecretId,
+			SM_JFROG_BASE_URL:  &url.URL{Scheme: "https", Host: "jfrog.example.com", Path: "/"},
+		}
+		mockRestyClient := new(MockRestyClient)
+		resp := resty.Response{
+			RawResponse: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+			},
+		}
+		resp.SetBody([]byte(`{"errors": [{"code": "NOT_FOUND", "message": "Token not found"}]}`))
+		mockRestyClient.On("Delete", mock.Anything, mock.Anything).Return(&resp, nil)
+
+		err := RunTask(context.Background(), mockSMClient, &config, &AccessTokenProvider{restyClient: mockRestyClient})
+
+		assert.ErrorContains(t, err, "Token not found")
+		mockSMClient.AssertTaskFailed(t, ErrAccessTokenNotRevoked)
+	})
+}
+
+// TestRunTaskEndToEnd tests the job with the SDK client against FakeSecretsManagerServer, which serves the login secret
+func TestRunTaskEndToEnd(t *testing.T) {
+	loginSecretId := "login-secret-id"
+	server := NewFakeSecretsManagerServer().
+		AddSecret(loginSecretId, NewMockArbitrarySecret(loginSecretId, "jfrog-bearer-token"))
+	defer server.Close()
+	config := Config{
+		SM_ACTION:          sm.SecretTask_Type_CreateCredentials,
+		SM_SECRET_ID:       "test-secret-id",
+		SM_SECRET_TASK_ID:  "test-secret-task-id",
+		SM_LOGIN_SECRET_ID: loginSecretId,
+		SM_JFROG_BASE_URL:  &url.URL{Scheme: "https", Host: "jfrog.example.com", Path: "/"},
+	}
+	server.Configure(&config)
+	smClient, err := NewSecretsManagerClient(config)
+	assert.NoError(t, err)
+
+	mockRestyClient := new(MockRestyClient)
+	resp := resty.Response{
+		RawResponse: &http.Response{
+			StatusCode: http.StatusOK,
+		},
+	}
+	resp.SetBody([]byte(fmt.Sprintf(`{"access_token": "%s", "token_id": "%s"}`, JFrogValidAccessToken, JFrogValidTokenId)))
+	mockRestyClient.On("Post", "jfrog-bearer-token", mock.Anything, "https://jfrog.example.com/access/api/v1/tokens/").
+		Return(&resp, nil)
+
+	err = RunTask(context.Background(), smClient, &config, &AccessTokenProvider{restyClient: mockRestyClient})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.RequestCount(FakeEndpointGetSecret))
+	updates := server.TaskUpdates()
+	if assert.Len(t, updates, 1) {
+		assert.Equal(t, sm.SecretTask_Status_CredentialsCreated, updates[0].To)
+		assert.Equal(t, JFrogValidTokenId, updates[0].CredentialsID)
+		assert.Equal(t, JFrogValidAccessToken, updates[0].Credentials["access_token"])
+	}
+}
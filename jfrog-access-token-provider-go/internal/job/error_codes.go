@@ -1,35 +0,0 @@
-package job
-
-const (
-	Err10000 = "ERR10000"
-	Err10001 = "ERR10001"
-	Err10002 = "ERR10002"
-	Err10003 = "ERR10003"
-	Err10004 = "ERR10004"
-	Err10005 = "ERR10005"
-	Err10006 = "ERR10006"
-	Err10007 = "ERR10007"
-	Err10008 = "ERR10008"
-	Err10009 = "ERR10009"
-	Err10010 = "ERR10010"
-	Err10011 = "ERR10011"
-	Err10012 = "ERR10012"
-	Err10013 = "ERR10013"
-	Err10014 = "ERR10014"
-	Err10015 = "ERR10015"
-	Err10016 = "ERR10016"
-	Err10017 = "ERR10017"
-	Err10018 = "ERR10018"
-	Err10019 = "ERR10019"
-	Err10020 = "ERR10020"
-	Err10021 = "ERR10021"
-	Err10022 = "ERR10022"
-	Err10023 = "ERR10023"
-	Err10024 = "ERR10024"
-	Err10025 = "ERR10025"
-	Err10026 = "ERR10026"
-	Err10027 = "ERR10027"
-	Err10028 = "ERR10028"
-	Err10029 = "ERR10029"
-	Err10030 = "ERR10030"
-)
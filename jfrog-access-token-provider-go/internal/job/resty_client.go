@@ -0,0 +1,31 @@
+package job
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type RestyClientIntf interface {
+	Post(ctx context.Context, authToken string, body interface{}, url string) (*resty.Response, error)
+	Delete(ctx context.Context, authToken string, url string) (*resty.Response, error)
+}
+
+type RestyClientStruct struct {
+	Client *resty.Client
+}
+
+func (r *RestyClientStruct) Post(ctx context.Context, authToken string, body interface{}, url string) (*resty.Response, error) {
+	return r.Client.R().
+		SetContext(ctx).
+		SetAuthToken(authToken).
+		SetBody(body).
+		Post(url)
+}
+
+func (r *RestyClientStruct) Delete(ctx context.Context, authToken string, url string) (*resty.Response, error) {
+	return r.Client.R().
+		SetContext(ctx).
+		SetAuthToken(authToken).
+		Delete(url)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"job-code-generator/jobconfig"
+)
+
+// errorCatalogFileName is the name of the optional error catalog file in the job directory
+const errorCatalogFileName = "error_codes.json"
+
+// Marker comments that delimit the generated error code table in a provider README
+const (
+	errorCodeTableBeginMarker = "<!-- BEGIN GENERATED ERROR CODES -->"
+	errorCodeTableEndMarker   = "<!-- END GENERATED ERROR CODES -->"
+)
+
+// loadErrorCatalog reads and validates the error catalog file of a job configuration. A missing file is an empty catalog.
+func loadErrorCatalog(catalogPath string, userSchema *jobconfig.JobConfig) *jobconfig.ErrorCatalog {
+	data, err := os.ReadFile(catalogPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &jobconfig.ErrorCatalog{}
+	}
+	if err != nil {
+		fmt.Printf("Error reading error catalog file: %v\n", err)
+		os.Exit(1)
+	}
+	return parseErrorCatalog(catalogPath, data, userSchema)
+}
+
+// parseErrorCatalog parses and validates the content of the error catalog file of a job configuration, and exits if it is invalid
+func parseErrorCatalog(catalogPath string, data []byte, userSchema *jobconfig.JobConfig) *jobconfig.ErrorCatalog {
+	catalog, diagnostics := jobconfig.ParseErrorCatalog(data, userSchema)
+	if len(diagnostics) > 0 {
+		fmt.Printf("Invalid error catalog. Found %d validation errors:\n", len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s:%s\n", catalogPath, diagnostic)
+		}
+		os.Exit(1)
+	}
+	return catalog
+}
+
+// jobErrorCodes returns the error codes that a job can report, ordered by code: the codes of its error catalog and
+// the builtin codes. The secret resolver codes are left out if the job has no secret_id inputs.
+func jobErrorCodes(userSchema *jobconfig.JobConfig, catalog *jobconfig.ErrorCatalog) []jobconfig.ErrorCode {
+	hasSecretResolvers := slices.ContainsFunc(userSchema.JobEnvVariables, func(envVar jobconfig.JobEnvVariable) bool {
+		attrType, _, err := jobconfig.ParseAttributes(envVar.Value)
+		return err == nil && attrType == "secret_id" && strings.HasPrefix(envVar.Name, "SMIN_")
+	})
+
+	errorCodes := slices.Clone(catalog.ErrorCodes)
+	for _, builtin := range jobconfig.BuiltinErrorCodes {
+		if hasSecretResolvers || !slices.Contains(jobconfig.SecretResolverErrorCodes, builtin.Code) {
+			errorCodes = append(errorCodes, builtin)
+		}
+	}
+	slices.SortStableFunc(errorCodes, func(a, b jobconfig.ErrorCode) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+	return errorCodes
+}
+
+// markErrorCodeTable wraps the error code table in the marker comments
+func markErrorCodeTable(table string) string {
+	return errorCodeTableBeginMarker + "\n\n" + table + "\n" + errorCodeTableEndMarker
+}
+
+// ReplaceErrorCodeTable replaces the content between the error code table marker comments of a README with the given table
+func ReplaceErrorCodeTable(readme, table string) (string, error) {
+	if strings.Count(readme, errorCodeTableBeginMarker) != 1 || strings.Count(readme, errorCodeTableEndMarker) != 1 {
+		return "", fmt.Errorf("the README must contain the marker comments %s and %s exactly once", errorCodeTableBeginMarker, errorCodeTableEndMarker)
+	}
+	begin := strings.Index(readme, errorCodeTableBeginMarker)
+	end := strings.Index(readme, errorCodeTableEndMarker)
+	if end < begin {
+		return "", fmt.Errorf("the marker comment %s must come before %s", errorCodeTableBeginMarker, errorCodeTableEndMarker)
+	}
+	return readme[:begin] + markErrorCodeTable(table) + readme[end+len(errorCodeTableEndMarker):], nil
+}
+
+// RenderErrorCodeTable renders the Markdown table of the error codes that a job reports to Secrets Manager
+func RenderErrorCodeTable(errorCodes []jobconfig.ErrorCode) string {
+	var rows [][]string
+	for _, errorCode := range errorCodes {
+		retryable := "No"
+		if errorCode.Retryable {
+			retryable = "Yes"
+		}
+		rows = append(rows, []string{"`" + errorCode.Code + "`", errorCode.Category, errorCode.Message, errorCode.Remediation, retryable})
+	}
+
+	var tableBuilder strings.Builder
+	tableBuilder.WriteString("The error codes that the job reports to Secrets Manager when the secret task fails. A retryable error can disappear in a later run of the task without changes, e.g. after an outage of the target system:\n\n")
+	writeMarkdownTable(&tableBuilder, []string{"Code", "Category", "Message", "Remediation", "Retryable"}, rows)
+	return tableBuilder.String()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"job-code-generator/jobconfig"
+)
+
+// TestJobErrorCodes tests that the error codes of a job are those of its catalog and the builtin codes that it can report
+func TestJobErrorCodes(t *testing.T) {
+	catalog := &jobconfig.ErrorCatalog{ErrorCodes: []jobconfig.ErrorCode{
+		{Code: "ERR10002", Name: "RoleNotDeleted", Category: "upstream", Message: "failed"},
+		{Code: "ERR10001", Name: "RoleNotCreated", Category: "upstream", Message: "failed"},
+	}}
+	testCases := []struct {
+		name                    string
+		inputType               string
+		expectedSecretResolvers bool
+	}{
+		{
+			name:                    "Job with a secret_id input",
+			inputType:               "secret_id",
+			expectedSecretResolvers: true,
+		},
+		{
+			name:                    "Job without secret_id inputs",
+			inputType:               "string",
+			expectedSecretResolvers: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userSchema := &jobconfig.JobConfig{JobEnvVariables: []jobconfig.JobEnvVariable{
+				{Name: "SMIN_LOGIN", Value: "type:" + tc.inputType + ", required:true"},
+				{Name: "SMOUT_TOKEN", Value: "type:string, required:true"},
+			}}
+
+			var codes []string
+			for _, errorCode := range jobErrorCodes(userSchema, catalog) {
+				codes = append(codes, errorCode.Code)
+			}
+
+			if !slices.IsSorted(codes) {
+				t.Errorf("expected the error codes to be sorted, got %v", codes)
+			}
+			if codes[0] != "ERR10001" || codes[1] != "ERR10002" {
+				t.Errorf("expected the catalog codes first, got %v", codes)
+			}
+			for _, builtin := range jobconfig.BuiltinErrorCodes {
+				expected := tc.expectedSecretResolvers || !slices.Contains(jobconfig.SecretResolverErrorCodes, builtin.Code)
+				if slices.Contains(codes, builtin.Code) != expected {
+					t.Errorf("expected builtin code %s to be listed: %t, got %v", builtin.Code, expected, codes)
+				}
+			}
+		})
+	}
+}
+
+// TestReplaceErrorCodeTable tests that only the content between the error code marker comments of a README is replaced
+func TestReplaceErrorCodeTable(t *testing.T) {
+	testCases := []struct {
+		name          string
+		readme        string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Table replaced",
+			readme:   "### Error Codes\n\n" + errorCodeTableBeginMarker + "\n\nold table\n" + errorCodeTableEndMarker + "\n\n## Development\n",
+			expected: "### Error Codes\n\n" + errorCodeTableBeginMarker + "\n\nnew table\n\n" + errorCodeTableEndMarker + "\n\n## Development\n",
+		},
+		{
+			name:          "Missing markers",
+			readme:        "### Error Codes\n",
+			expectedError: "exactly once",
+		},
+		{
+			name:          "Markers in the wrong order",
+			readme:        errorCodeTableEndMarker + "\n" + errorCodeTableBeginMarker + "\n",
+			expectedError: "must come before",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ReplaceErrorCodeTable(tc.readme, "new table\n")
+
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected an error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected README:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -12,150 +12,69 @@ BLUE='\033[38;2;41;184;219m'  # #29B8DB in RGB
 RED='\033[0;31m'             # Red color for errors
 RESET='\033[0m'               # Reset text color
 
-# Function to validate environment variables
-validate_env_variables() {
-    local config_file="$1"
-    local has_errors=false
-    local has_required_smout=false
-
-    # Check if jq is installed
-    if ! command -v jq &> /dev/null; then
-        echo -e "${RED}Error: jq is required but not installed. Please install jq.${RESET}"
-        return 1
-    fi
-
-    # First validate that the JSON is well-formed
-    if ! jq empty "$config_file" 2>/tmp/jq_error; then
-        echo -e "${RED}Error: Invalid JSON format in $config_file${RESET}"
-        cat /tmp/jq_error
-        rm -f /tmp/jq_error
-        return 1
-    fi
-    
-    # Make sure job_env_variables exists and is an array
-    if ! jq -e '.job_env_variables | type=="array"' "$config_file" >/dev/null 2>&1; then
-        echo -e "${RED}Error: job_env_variables is missing or not an array in $config_file${RESET}"
+# Directory of this script, which also holds the source of the job code generator
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+
+# Function to validate the job configuration file with the "validate" subcommand of the job code generator,
+# which implements the rules of the contract with Secrets Manager for both the generator and this script
+validate_job_config() {
+    local config_file
+    config_file="$(cd "$(dirname "$1")" && pwd)/$(basename "$1")"
+
+    # Run the generator from source, which builds it for the platform of this machine
+    if ! command -v go &> /dev/null; then
+        echo -e "${RED}Error: Go is required to validate $config_file with the job code generator. Please install Go.${RESET}"
         return 1
     fi
+    go run -C "$SCRIPT_DIR" . validate -config="$config_file"
+}
 
-    # Extract all environment variables
-    local num_vars=$(jq '.job_env_variables | length' "$config_file")
-    
-    for (( i=0; i<$num_vars; i++ )); do
-        # Get name and value
-        local name=$(jq -r ".job_env_variables[$i].name" "$config_file")
-        local value=$(jq -r ".job_env_variables[$i].value" "$config_file")
-        
-        # Check if name and value exist
-        if [[ "$name" == "null" ]]; then
-            echo -e "${RED}Error: Missing 'name' field for variable at index $i${RESET}"
-            has_errors=true
-            continue
-        fi
-        
-        if [[ "$value" == "null" ]]; then
-            echo -e "${RED}Error: Missing 'value' field for variable '$name'${RESET}"
-            has_errors=true
-            continue
-        fi
-        
-        # Check 1: Variable name must start with SMIN_ or SMOUT_
-        if [[ ! "$name" =~ ^(SMIN_|SMOUT_) ]]; then
-            echo -e "${RED}Error: Variable name '$name' must start with 'SMIN_' or 'SMOUT_'${RESET}"
-            has_errors=true
-        fi
-
-        # Check 2: Variable name should not contain lowercase characters
-        if [[ "$name" =~ [a-z] ]]; then
-            echo -e "${RED}Error: Variable name '$name' should not contain lowercase characters${RESET}"
-            has_errors=true
-        fi
+# Function to remove the description attribute, which extends to the end of a variable value, e.g. "type:string, description:The name" returns "type:string"
+strip_description() {
+    echo "$1" | sed -E 's/(^|,)[[:space:]]*description[[:space:]]*:.*$//'
+}
 
-        # Check 3: Variable name should not have spaces
-        if [[ "$name" =~ [[:space:]] ]]; then
-            echo -e "${RED}Error: Variable name '$name' should not contain spaces${RESET}"
-            has_errors=true
-        fi
+# Function to remove the leading and trailing whitespace of a string with parameter expansion, which keeps quotes and backslashes unlike xargs
+trim() {
+    local value="$1"
+    value="${value#"${value%%[![:space:]]*}"}"
+    value="${value%"${value##*[![:space:]]}"}"
+    printf '%s\n' "$value"
+}
 
-        # Parse attributes from value
-        local has_type=false
-        local type_value=""
-        local required_value=""
-        
-        # Split value by comma and process each attribute
-        IFS=',' read -ra ATTRS <<< "$value"
-        for attr in "${ATTRS[@]}"; do
-            # Trim whitespace
-            attr=$(echo "$attr" | xargs)
-            
-            # Extract attribute name and value
-            if [[ "$attr" =~ ^([^:]+):(.+)$ ]]; then
-                local attr_name="${BASH_REMATCH[1]}"
-                local attr_val="${BASH_REMATCH[2]}"
-                
-                # Trim whitespace
-                attr_name=$(echo "$attr_name" | xargs)
-                attr_val=$(echo "$attr_val" | xargs)
-                
-                # Check attribute name
-                if [[ "$attr_name" == "type" ]]; then
-                    has_type=true
-                    type_value="$attr_val"
-                    
-                    # Check type value is valid
-                    if [[ "$attr_val" =~ ^enum\[ ]]; then
-                        # Check enum format (enum[optionA|optionB|...])
-                        if [[ ! "$attr_val" =~ ^enum\[[^]]+\]$ ]]; then
-                            echo -e "${RED}Error: Variable '$name' has invalid enum format. Expected: enum[optionA|optionB|...]${RESET}"
-                            has_errors=true
-                        fi
-                    elif [[ "$attr_val" != "string" && "$attr_val" != "integer" && "$attr_val" != "boolean" && "$attr_val" != "secret_id" && "$attr_val" != "enum" ]]; then
-                        echo -e "${RED}Error: Variable '$name' has invalid type '$attr_val'. Must be one of: string, integer, boolean, secret_id, enum${RESET}"
-                        has_errors=true
-                    fi
-                elif [[ "$attr_name" == "required" ]]; then
-                    required_value="$attr_val"
-                    
-                    # Check required value is valid
-                    if [[ "$attr_val" != "true" && "$attr_val" != "false" ]]; then
-                        echo -e "${RED}Error: Variable '$name' has invalid 'required' value '$attr_val'. Must be true or false${RESET}"
-                        has_errors=true
-                    fi
-                    
-                    # Check for SMOUT_ variable with required:true
-                    if [[ "$name" =~ ^SMOUT_ && "$attr_val" == "true" ]]; then
-                        has_required_smout=true
-                    fi
-                else
-                    # Invalid attribute
-                    echo -e "${RED}Error: Variable '$name' contains invalid attribute '$attr_name'${RESET}"
-                    has_errors=true
-                fi
-            else
-                # Attribute doesn't follow name:value format
-                echo -e "${RED}Error: Variable '$name' has invalid attribute format: '$attr'${RESET}"
-                has_errors=true
-            fi
-        done
-        
-        # Check 4: Value must contain "type" attribute
-        if [ "$has_type" = false ]; then
-            echo -e "${RED}Error: Variable '$name' value does not contain a 'type' attribute${RESET}"
-            has_errors=true
+# Function to extract a single attribute value from a variable value, e.g. "type:string, default:abc" and "default" returns "abc"
+get_attribute() {
+    local value="$1"
+    local attr_name="$2"
+
+    IFS=',' read -ra ATTRS <<< "$(strip_description "$value")"
+    for attr in "${ATTRS[@]}"; do
+        attr=$(trim "$attr")
+        if [[ "$attr" =~ ^([^:]+):(.+)$ ]] && [[ "$(trim "${BASH_REMATCH[1]}")" == "$attr_name" ]]; then
+            trim "${BASH_REMATCH[2]}"
+            return 0
         fi
     done
-    
-    # Check that at least one SMOUT_ variable with required:true is defined
-    if [ "$has_required_smout" = false ]; then
-        echo -e "${RED}Error: At least one SMOUT_ variable with attribute required:true must be defined${RESET}"
-        has_errors=true
-    fi
+    return 1
+}
 
-    # Return 1 if there are errors, 0 otherwise
-    if [ "$has_errors" = true ]; then
-        return 1
+# Function to build the variable value passed to Code Engine.
+# Secrets Manager only reads the 'type' and 'required' attributes, the other attributes are used by the job code generator.
+# The string_list, json, duration and url types are passed as strings by Secrets Manager and parsed by the job.
+deployed_value() {
+    local value="$1"
+    local type
+    type="$(get_attribute "$value" "type")"
+    case "$type" in
+        string_list|json|duration|url) type="string" ;;
+    esac
+    local deployed="type:$type"
+    local required
+
+    if required=$(get_attribute "$value" "required"); then
+        deployed="$deployed, required:$required"
     fi
-    return 0
+    echo "$deployed"
 }
 
 # Parse command line arguments
@@ -196,9 +115,15 @@ if [ ! -f "$DOCKERFILE" ]; then
     exit 1
 fi
 
+# Check if jq is installed, it is used to read the variables of job_config.json
+if ! command -v jq &> /dev/null; then
+    echo -e "${RED}Error: jq is required but not installed. Please install jq.${RESET}"
+    exit 1
+fi
+
 # Validate environment variables in job_config.json
 echo "Validating environment variables in $CONFIG_FILE..."
-if ! validate_env_variables "$CONFIG_FILE"; then
+if ! validate_job_config "$CONFIG_FILE"; then
     echo -e "${RED}Error: Invalid environment variables in $CONFIG_FILE. Please fix the issues and try again.${RESET}"
     exit 1
 fi
@@ -228,7 +153,7 @@ if jq empty "$CONFIG_FILE" 2>/dev/null; then
         
         # Extract name and value
         name=$(echo "$line" | cut -d' ' -f1)
-        value=${line#* }
+        value=$(deployed_value "${line#* }")
         
         # Print each environment variable on a new line
         echo -e "${BLUE}  --env $name=\"$value\" ${RESET}"
@@ -244,6 +169,24 @@ fi
 # Remove the trailing backslash from the display
 echo -e "${BLUE}${RESET}"
 
+# Show the default values that the job applies to optional input variables
+if jq empty "$CONFIG_FILE" 2>/dev/null; then
+    DEFAULTS=""
+    while read -r line; do
+        if [ -z "$line" ]; then
+            continue
+        fi
+        name=$(echo "$line" | cut -d' ' -f1)
+        if default_value=$(get_attribute "${line#* }" "default"); then
+            DEFAULTS="$DEFAULTS\n  $name=$default_value"
+        fi
+    done < <(jq -r '.job_env_variables[] | "\(.name) \(.value)"' "$CONFIG_FILE" 2>/dev/null)
+
+    if [ -n "$DEFAULTS" ]; then
+        echo -e "Default values applied by the job when an optional input is not set:$DEFAULTS"
+    fi
+fi
+
 # Build the full command for execution
 if [ "$ACTION" == "create" ]; then
     CMD="ibmcloud ce job create --name $JOB_NAME --build-source $JOB_DIR --build-dockerfile Dockerfile --retrylimit 0 --cpu 0.5 --memory 1G $ENV_FLAGS"
@@ -0,0 +1,277 @@
+package jobconfig
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Attributes accepted in a job configuration variable value in addition to 'type'.
+var supportedAttributes = map[string]bool{
+	"required":     true,
+	"default":      true,
+	"min":          true,
+	"max":          true,
+	"minlen":       true,
+	"maxlen":       true,
+	"pattern":      true,
+	"secret_types": true,
+	"field":        true,
+	"description":  true,
+}
+
+// SecretTypes are the secret types, sorted by name, that the resolver of a 'secret_id' input variable can read.
+// A variable without the 'secret_types' attribute accepts all of them.
+var SecretTypes = []string{
+	"arbitrary",
+	"custom_credentials",
+	"iam_credentials",
+	"kv",
+	"service_credentials",
+	"username_password",
+}
+
+// ReservedIdentifiers are the exported top-level identifiers, sorted by name, that the templates declare in the
+// package of the generated code whatever the job configuration and the error catalog. The identifiers generated
+// for the variables and the error codes, e.g. the enum types, must not conflict with them.
+var ReservedIdentifiers = []string{
+	"Config",
+	"ConfigError",
+	"ConfigFieldError",
+	"ConfigFromEnv",
+	"CredentialsPayload",
+	"CredentialsProvider",
+	"DefaultDryRunEnvFile",
+	"DefaultDryRunFixturesDir",
+	"DefaultJobTimeout",
+	"DryRunClient",
+	"ErrorCatalog",
+	"ErrorCategory",
+	"ErrorCategoryAuth",
+	"ErrorCategoryConfig",
+	"ErrorCategoryInternal",
+	"ErrorCategoryUpstream",
+	"ErrorCode",
+	"ErrorCodeInfo",
+	"FakeAPIKey",
+	"FakeEndpoint",
+	"FakeEndpointGetSecret",
+	"FakeEndpointGetSecretTask",
+	"FakeEndpointIAMToken",
+	"FakeEndpointReplaceSecretTask",
+	"FakeFailure",
+	"FakeRequest",
+	"FakeSecretsManagerServer",
+	"FakeTaskUpdate",
+	"GetEnvVar",
+	"GetSecret",
+	"GetValueByPath",
+	"IAMURL",
+	"IsRetryable",
+	"LoggerFromContext",
+	"MaxCredentialsPayloadSize",
+	"MaxTaskErrorDescriptionLength",
+	"MaxTaskErrors",
+	"MockNewCredentialsCall",
+	"MockSecretTaskErrorCall",
+	"MockSecretsManagerClient",
+	"MustGetEnvVar",
+	"NewDryRunClient",
+	"NewFakeSecretsManagerServer",
+	"NewLogHandler",
+	"NewLogger",
+	"NewMockArbitrarySecret",
+	"NewMockCustomCredentialsSecret",
+	"NewMockSecretsManagerClient",
+	"NewMockServiceCredentialsSecret",
+	"NewSecretsManagerClient",
+	"NewTaskError",
+	"ParseTrigger",
+	"Redact",
+	"ResolvedSecret",
+	"RetryPolicy",
+	"Run",
+	"RunDryRun",
+	"RunTask",
+	"SMClient",
+	"SecretResolutionError",
+	"SecretsManagerClient",
+	"TaskError",
+	"TaskErrorf",
+	"TaskErrors",
+	"TaskHook",
+	"TaskHooks",
+	"TaskReportTimeout",
+	"TaskUpdateRetryPolicy",
+	"TestingT",
+	"Trigger",
+	"TriggerAutomaticSecretRotation",
+	"TriggerHooks",
+	"TriggerManualSecretRotation",
+	"TriggerSecretCreation",
+	"TriggerSecretVersionDataDeletion",
+	"TriggerSecretVersionExpiration",
+	"UpdateTask",
+	"UpdateTaskAboutCredentialsCreated",
+	"UpdateTaskAboutCredentialsDeleted",
+	"UpdateTaskAboutError",
+	"UpdateTaskAboutErrors",
+	"ValidatedStructToMap",
+	"WithRemediation",
+}
+
+// isReservedIdentifier reports whether an identifier is declared by the templates or is the constant of a builtin error code
+func isReservedIdentifier(identifier string) bool {
+	if slices.Contains(ReservedIdentifiers, identifier) {
+		return true
+	}
+	return slices.ContainsFunc(BuiltinErrorCodes, func(builtin ErrorCode) bool {
+		return ErrorConstName(builtin.Name) == identifier
+	})
+}
+
+// Config field names declared by the generated code for the service variables and the job settings.
+// The 'SMIN_' variables, e.g. SMIN_ACTION for the SM_ACTION field, must not conflict with them.
+var reservedFieldNames = map[string]bool{
+	"SM_ACCESS_APIKEY":            true,
+	"SM_ACTION":                   true,
+	"SM_AUTH_TYPE":                true,
+	"SM_CR_TOKEN_FILENAME":        true,
+	"SM_CREDENTIALS_ID":           true,
+	"SM_IAM_URL":                  true,
+	"SM_INSTANCE_URL":             true,
+	"SM_JOB_TIMEOUT":              true,
+	"SM_LOG_LEVEL":                true,
+	"SM_SECRET_GROUP_ID":          true,
+	"SM_SECRET_ID":                true,
+	"SM_SECRET_NAME":              true,
+	"SM_SECRET_TASK_ID":           true,
+	"SM_SECRET_VERSION_ID":        true,
+	"SM_TASK_UPDATE_MAX_ATTEMPTS": true,
+	"SM_TASK_UPDATE_MAX_BACKOFF":  true,
+	"SM_TRIGGER":                  true,
+	"SM_TRUSTED_PROFILE_ID":       true,
+	"SM_TRUSTED_PROFILE_NAME":     true,
+}
+
+// descriptionAttributePattern matches the start of the description attribute, which extends to the end of the value
+var descriptionAttributePattern = regexp.MustCompile(`(^|,)\s*description\s*:`)
+
+// ParseAttributes extracts the type and validation rules from an attribute string
+func ParseAttributes(value string) (string, map[string]string, error) {
+	// Initialize empty map for validation attributes
+	validations := make(map[string]string)
+
+	// Initialize empty string for type
+	var attrType string
+
+	// Trim whitespace
+	value = strings.TrimSpace(value)
+
+	// The description is the last attribute and can contain commas, so split it off first
+	if loc := descriptionAttributePattern.FindStringIndex(value); loc != nil {
+		description := strings.TrimSpace(value[loc[1]:])
+		if description == "" {
+			return "", nil, fmt.Errorf("attribute key and value cannot be empty")
+		}
+		validations["description"] = description
+		value = strings.TrimSpace(value[:loc[0]])
+		if value == "" {
+			return "", validations, nil
+		}
+	}
+
+	// Split by comma to get individual attributes
+	attributes := strings.Split(value, ",")
+
+	for _, attr := range attributes {
+		// Trim whitespace from each attribute
+		attr = strings.TrimSpace(attr)
+
+		// Skip empty attributes
+		if attr == "" {
+			return "", nil, fmt.Errorf("attribute cannot be empty")
+		}
+
+		// Split attribute into key and value
+		parts := strings.SplitN(attr, ":", 2)
+		if len(parts) != 2 {
+			return "", nil, fmt.Errorf("attribute must be in 'key:value' format")
+		}
+
+		key := strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+
+		// Check for empty key or value
+		if key == "" || val == "" {
+			return "", nil, fmt.Errorf("attribute key and value cannot be empty")
+		}
+
+		// Handle the attributes based on key
+		if key == "type" {
+			attrType = val
+		} else {
+			validations[key] = val
+		}
+	}
+
+	return attrType, validations, nil
+}
+
+// IsEnumType reports whether the attribute type is an enum declaration
+func IsEnumType(attrType string) bool {
+	return strings.HasPrefix(attrType, "enum[") && strings.HasSuffix(attrType, "]")
+}
+
+// EnumOptions returns the options of an enum attribute type, e.g. enum[RSA|ECDSA] returns [RSA ECDSA]
+func EnumOptions(attrType string) []string {
+	options := strings.Split(attrType[len("enum["):len(attrType)-1], "|")
+	for i := range options {
+		options[i] = strings.TrimSpace(options[i])
+	}
+	return options
+}
+
+// EnumTypeName returns the Go type name for an enum variable, e.g. SMIN_KEY_ALGO returns KeyAlgo
+func EnumTypeName(variableName string) string {
+	name := strings.TrimPrefix(variableName, "SMIN_")
+	return toCamelCase(strings.ToLower(name))
+}
+
+// SecretResolverName returns the name of the generated resolver of a secret_id input variable,
+// e.g. SMIN_LOGIN_SECRET_ID returns ResolveLoginSecretId
+func SecretResolverName(variableName string) string {
+	return "Resolve" + EnumTypeName(variableName)
+}
+
+// SecretTypeOptions returns the secret types of a 'secret_types' attribute, e.g. arbitrary|kv returns [arbitrary kv]
+func SecretTypeOptions(secretTypes string) []string {
+	options := strings.Split(secretTypes, "|")
+	for i := range options {
+		options[i] = strings.TrimSpace(options[i])
+	}
+	return options
+}
+
+// EnumConstName returns the Go constant name for an enum option, e.g. KeyAlgo and RSA returns KeyAlgoRSA
+func EnumConstName(typeName, option string) string {
+	return typeName + toCamelCase(option)
+}
+
+// nonAlphanumericPattern matches the separators of the parts joined by toCamelCase
+var nonAlphanumericPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// toCamelCase joins the ASCII alphanumeric parts of the value with their first letter in upper case.
+// It returns an empty string if the value has no ASCII letters or digits.
+func toCamelCase(value string) string {
+	parts := nonAlphanumericPattern.Split(value, -1)
+	var builder strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return builder.String()
+}
@@ -0,0 +1,136 @@
+package jobconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxOutputLength is the maximum length of a string output variable accepted by Secrets Manager.
+const MaxOutputLength = 100000
+
+// MaxPayloadSize is the maximum size, in bytes, of the serialized credentials of a custom credentials secret
+// accepted by Secrets Manager.
+const MaxPayloadSize = 100000
+
+// Constraints holds the range, length and pattern attributes declared for a variable.
+type Constraints struct {
+	Min     *int
+	Max     *int
+	MinLen  *int
+	MaxLen  *int
+	Pattern string
+}
+
+// ParseConstraints extracts the constraint attributes from the validation attributes of a variable
+func ParseConstraints(validations map[string]string) (Constraints, error) {
+	var c Constraints
+	for key, target := range map[string]**int{"min": &c.Min, "max": &c.Max, "minlen": &c.MinLen, "maxlen": &c.MaxLen} {
+		val, ok := validations[key]
+		if !ok {
+			continue
+		}
+		number, err := strconv.Atoi(val)
+		if err != nil {
+			return Constraints{}, fmt.Errorf("attribute '%s' value '%s' is not a valid integer", key, val)
+		}
+		*target = &number
+	}
+	c.Pattern = validations["pattern"]
+	return c, nil
+}
+
+// validateConstraints checks that the constraint attributes are well-formed and apply to the declared type
+func validateConstraints(name, attrType string, validations map[string]string) []string {
+	var messages []string
+
+	c, err := ParseConstraints(validations)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	isString := attrType == "string" || attrType == "secret_id"
+	if (c.Min != nil || c.Max != nil) && attrType != "integer" {
+		messages = append(messages, "Attributes 'min' and 'max' are only supported for 'integer' variables")
+	}
+	if (c.MinLen != nil || c.MaxLen != nil || c.Pattern != "") && !isString {
+		messages = append(messages, "Attributes 'minlen', 'maxlen' and 'pattern' are only supported for 'string' and 'secret_id' variables")
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		messages = append(messages, fmt.Sprintf("Attribute 'min' (%d) cannot be greater than 'max' (%d)", *c.Min, *c.Max))
+	}
+	if (c.MinLen != nil && *c.MinLen < 0) || (c.MaxLen != nil && *c.MaxLen < 0) {
+		messages = append(messages, "Attributes 'minlen' and 'maxlen' cannot be negative")
+	}
+	if c.MinLen != nil && c.MaxLen != nil && *c.MinLen > *c.MaxLen {
+		messages = append(messages, fmt.Sprintf("Attribute 'minlen' (%d) cannot be greater than 'maxlen' (%d)", *c.MinLen, *c.MaxLen))
+	}
+	if strings.HasPrefix(name, "SMOUT_") && c.MaxLen != nil && *c.MaxLen > MaxOutputLength {
+		messages = append(messages, fmt.Sprintf("Attribute 'maxlen' cannot be greater than %d for output variables", MaxOutputLength))
+	}
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			messages = append(messages, fmt.Sprintf("Attribute 'pattern' is not a valid regular expression: %v", err))
+		}
+	}
+
+	// The default value must satisfy the declared constraints
+	if defaultVal, ok := validations["default"]; ok && len(messages) == 0 {
+		if msg := c.check(attrType, defaultVal); msg != "" {
+			messages = append(messages, fmt.Sprintf("Default value '%s' %s", defaultVal, msg))
+		}
+	}
+
+	return messages
+}
+
+// check returns the first constraint violated by the given value, or an empty string if the value is valid
+func (c Constraints) check(attrType, value string) string {
+	if attrType == "integer" {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return ""
+		}
+		if c.Min != nil && number < *c.Min {
+			return fmt.Sprintf("must be at least %d", *c.Min)
+		}
+		if c.Max != nil && number > *c.Max {
+			return fmt.Sprintf("must be at most %d", *c.Max)
+		}
+		return ""
+	}
+
+	length := len([]rune(value))
+	if c.MinLen != nil && length < *c.MinLen {
+		return fmt.Sprintf("must be at least %d characters long", *c.MinLen)
+	}
+	if c.MaxLen != nil && length > *c.MaxLen {
+		return fmt.Sprintf("must be at most %d characters long", *c.MaxLen)
+	}
+	if c.Pattern != "" && !regexp.MustCompile(c.Pattern).MatchString(value) {
+		return fmt.Sprintf("must match the pattern '%s'", c.Pattern)
+	}
+	return ""
+}
+
+// ValidateTags returns the validator tags that enforce the constraints, without the 'required' tag
+func (c Constraints) ValidateTags() []string {
+	var tags []string
+	if c.Min != nil {
+		tags = append(tags, fmt.Sprintf("min=%d", *c.Min))
+	}
+	if c.Max != nil {
+		tags = append(tags, fmt.Sprintf("max=%d", *c.Max))
+	}
+	if c.MinLen != nil {
+		tags = append(tags, fmt.Sprintf("min=%d", *c.MinLen))
+	}
+	if c.MaxLen != nil {
+		tags = append(tags, fmt.Sprintf("max=%d", *c.MaxLen))
+	}
+	if c.Pattern != "" {
+		tags = append(tags, "pattern")
+	}
+	return tags
+}
@@ -0,0 +1,280 @@
+package jobconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrorCode is an entry of the error catalog of a credentials provider, the error_codes.json file
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+	Retryable   bool   `json:"retryable,omitempty"`
+}
+
+// ErrorCatalog represents the error catalog of a credentials provider
+type ErrorCatalog struct {
+	ErrorCodes []ErrorCode `json:"error_codes"`
+}
+
+// ErrorCategories are the allowed categories of an error code
+var ErrorCategories = []string{"config", "auth", "upstream", "internal"}
+
+// ErrorParameterName is the message placeholder of the error that caused a catalog error
+const ErrorParameterName = "error"
+
+// BuiltinErrorCodes are the error codes reported by the generated job code. The provider codes must not reuse
+// their codes or names.
+var BuiltinErrorCodes = []ErrorCode{
+	{Code: "ERR11001", Name: "SecretUnavailable", Category: "upstream", Message: "The secret of an input cannot be fetched from Secrets Manager", Remediation: "check that the secret exists and that the job can read it", Retryable: true},
+	{Code: "ERR11002", Name: "SecretTypeNotAllowed", Category: "config", Message: "The secret of an input is not of an allowed secret type", Remediation: "select a secret of one of the allowed types"},
+	{Code: "ERR11003", Name: "SecretFieldMissing", Category: "config", Message: "The secret of an input does not contain the required field", Remediation: "select a secret that contains the field"},
+	{Code: "ERR11004", Name: "CredentialsPayloadInvalid", Category: "internal", Message: "The created credentials do not match the output variables"},
+	{Code: "ERR11005", Name: "CredentialsPayloadTooLarge", Category: "internal", Message: "The created credentials exceed the size limit of Secrets Manager"},
+	{Code: "ERR11006", Name: "InvalidJobConfig", Category: "config", Message: "An input variable has an invalid value"},
+	{Code: "ERR11007", Name: "UnknownAction", Category: "internal", Message: "The action of the secret task is not supported"},
+	{Code: "ERR11008", Name: "CredentialsNotCreated", Category: "upstream", Message: "The credentials cannot be created", Retryable: true},
+	{Code: "ERR11009", Name: "CredentialsNotDeleted", Category: "upstream", Message: "The credentials cannot be deleted", Retryable: true},
+	{Code: "ERR11010", Name: "JobTimeout", Category: "internal", Message: "The job did not complete before its deadline", Remediation: "increase the max execution time of the job and SM_JOB_TIMEOUT", Retryable: true},
+	{Code: "ERR11011", Name: "ProviderPanic", Category: "internal", Message: "The credentials provider failed unexpectedly"},
+	{Code: "ERR11012", Name: "TriggerHookFailed", Category: "internal", Message: "The hook of the trigger of the secret task failed"},
+}
+
+// SecretResolverErrorCodes are the builtin codes that are only reported by jobs with secret_id inputs
+var SecretResolverErrorCodes = []string{"ERR11001", "ERR11002", "ERR11003"}
+
+// errorCodePattern is the format of the provider error codes. ERR11000 and above are reserved for the generated code.
+var errorCodePattern = regexp.MustCompile(`^ERR10[0-9]{3}$`)
+
+// errorNamePattern is the format of the error names, which name the generated Err<Name> constant and New<Name>Error constructor
+var errorNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// placeholderPattern matches the {parameter} placeholders of a message
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// parameterNamePattern is the format of the placeholder names, which name the parameters of the generated constructor
+var parameterNamePattern = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+
+// ParseErrorCatalog parses and validates the content of an error catalog file. The Go identifiers generated for the
+// error codes must not conflict with those generated for the variables of config, the job configuration of the provider.
+// The catalog is valid if no diagnostics are returned. Otherwise, it is nil if the content is not valid JSON.
+func ParseErrorCatalog(data []byte, config *JobConfig) (*ErrorCatalog, []Diagnostic) {
+	var catalog ErrorCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, []Diagnostic{jsonErrorDiagnostic(data, err)}
+	}
+
+	layout, diagnostics := locateErrorCatalog(data)
+	diagnostics = append(diagnostics, validateErrorCatalog(&catalog, layout, generatedIdentifiers(config))...)
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return &catalog, diagnostics
+}
+
+// ErrorConstName returns the name of the generated constant of an error code, e.g. ErrRoleNotCreated for RoleNotCreated
+func ErrorConstName(name string) string {
+	return "Err" + name
+}
+
+// ErrorConstructorName returns the name of the generated constructor of an error code, e.g. NewRoleNotCreatedError for
+// RoleNotCreated
+func ErrorConstructorName(name string) string {
+	return "New" + name + "Error"
+}
+
+// MessagePlaceholders returns the names of the placeholders of a message in order, including the repeated ones
+func MessagePlaceholders(message string) []string {
+	var placeholders []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(message, -1) {
+		placeholders = append(placeholders, match[1])
+	}
+	return placeholders
+}
+
+// MessageParameters returns the names of the placeholders of a message in the order of their first use
+func MessageParameters(message string) []string {
+	var parameters []string
+	for _, placeholder := range MessagePlaceholders(message) {
+		if !slices.Contains(parameters, placeholder) {
+			parameters = append(parameters, placeholder)
+		}
+	}
+	return parameters
+}
+
+// MessageFormat converts a message to a format string of fmt, with %w for the error placeholder and %s for the others
+func MessageFormat(message string) string {
+	format := strings.ReplaceAll(message, "%", "%%")
+	return placeholderPattern.ReplaceAllStringFunc(format, func(placeholder string) string {
+		if placeholder == "{"+ErrorParameterName+"}" {
+			return "%w"
+		}
+		return "%s"
+	})
+}
+
+// errorCatalogLayout holds the positions of the elements of an error catalog file
+type errorCatalogLayout struct {
+	errorCodes       Position // The error_codes array, or the start of the file if it is missing
+	errorCodeLayouts []map[string]Position
+}
+
+// errorCode returns the positions of the fields of the error code at the given index, keyed by the field name.
+// The empty key holds the position of the object, which is also used for the missing fields.
+func (l errorCatalogLayout) errorCode(index int) func(field string) Position {
+	fields := map[string]Position{"": l.errorCodes}
+	if index < len(l.errorCodeLayouts) {
+		fields = l.errorCodeLayouts[index]
+	}
+	return func(field string) Position {
+		if position, ok := fields[field]; ok {
+			return position
+		}
+		return fields[""]
+	}
+}
+
+// locateErrorCatalog walks the JSON tokens of an error catalog file that was decoded successfully to find the
+// positions of its elements. It reports the fields that are not part of the error catalog format.
+func locateErrorCatalog(data []byte) (errorCatalogLayout, []Diagnostic) {
+	s := &tokenScanner{data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	layout := errorCatalogLayout{errorCodes: Position{Line: 1, Column: 1}}
+	var diagnostics []Diagnostic
+
+	if token, _ := s.next(); token != json.Delim('{') {
+		return layout, nil
+	}
+	for s.decoder.More() && s.err == nil {
+		key, keyPosition := s.next()
+		switch key {
+		case "$schema":
+			s.skip()
+		case "error_codes":
+			token, position := s.next()
+			layout.errorCodes = position
+			if token != json.Delim('[') {
+				continue
+			}
+			for s.decoder.More() && s.err == nil {
+				layout.errorCodeLayouts = append(layout.errorCodeLayouts, s.locateErrorCode(&diagnostics))
+			}
+			s.next()
+		default:
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: keyPosition,
+				Message:  fmt.Sprintf("Unknown field '%v'. Only '$schema' and 'error_codes' are accepted", key),
+			})
+			s.skip()
+		}
+	}
+	return layout, diagnostics
+}
+
+// locateErrorCode reads an error code object and returns the positions of its fields
+func (s *tokenScanner) locateErrorCode(diagnostics *[]Diagnostic) map[string]Position {
+	token, position := s.next()
+	fields := map[string]Position{"": position}
+	if token != json.Delim('{') {
+		return fields
+	}
+	for s.decoder.More() && s.err == nil {
+		key, keyPosition := s.next()
+		switch key {
+		case "code", "name", "category", "message", "remediation", "retryable":
+			_, fields[key.(string)] = s.next()
+		default:
+			*diagnostics = append(*diagnostics, Diagnostic{
+				Position: keyPosition,
+				Message:  fmt.Sprintf("Unknown field '%v'. Only 'code', 'name', 'category', 'message', 'remediation' and 'retryable' are accepted", key),
+			})
+			s.skip()
+		}
+	}
+	s.next()
+	return fields
+}
+
+// validateErrorCatalog validates the error codes of a catalog. The diagnostics are positioned with the layout of the file.
+// variableIdentifiers holds the variables of the Go identifiers generated for the job configuration, keyed by identifier.
+func validateErrorCatalog(catalog *ErrorCatalog, layout errorCatalogLayout, variableIdentifiers map[string]string) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	seenCodes := make(map[string]bool)
+	seenNames := make(map[string]bool)
+	for _, builtin := range BuiltinErrorCodes {
+		seenNames[builtin.Name] = true
+	}
+	for i, errorCode := range catalog.ErrorCodes {
+		position := layout.errorCode(i)
+		report := func(field, message string) {
+			diagnostics = append(diagnostics, Diagnostic{Position: position(field), Message: fmt.Sprintf("Error code '%s': %s", errorCode.Code, message)})
+		}
+		if errorCode.Code == "" {
+			diagnostics = append(diagnostics, Diagnostic{Position: position(""), Message: fmt.Sprintf("Missing 'code' field for the error code at index %d", i)})
+			continue
+		}
+
+		if !errorCodePattern.MatchString(errorCode.Code) {
+			report("code", "Code must be between ERR10000 and ERR10999. The codes from ERR11000 are reserved for the generated code")
+		} else if seenCodes[errorCode.Code] {
+			report("code", "Code is defined more than once")
+		}
+		seenCodes[errorCode.Code] = true
+
+		switch {
+		case errorCode.Name == "":
+			report("", "Missing 'name' field")
+		case !errorNamePattern.MatchString(errorCode.Name):
+			report("name", "Name must be a Go identifier that starts with an uppercase letter, e.g. RoleNotCreated")
+		case seenNames[errorCode.Name]:
+			report("name", fmt.Sprintf("Name '%s' is already used by another error code", errorCode.Name))
+		default:
+			for _, identifier := range []string{ErrorConstName(errorCode.Name), ErrorConstructorName(errorCode.Name)} {
+				if isReservedIdentifier(identifier) {
+					report("name", fmt.Sprintf("Name '%s' names the Go identifier '%s', which conflicts with a Go identifier declared by the generated code", errorCode.Name, identifier))
+				} else if variable, ok := variableIdentifiers[identifier]; ok {
+					report("name", fmt.Sprintf("Name '%s' names the Go identifier '%s', which conflicts with a Go identifier generated for variable '%s'", errorCode.Name, identifier, variable))
+				}
+			}
+		}
+		seenNames[errorCode.Name] = true
+
+		if !slices.Contains(ErrorCategories, errorCode.Category) {
+			report("category", fmt.Sprintf("Category must be one of: %s", strings.Join(ErrorCategories, ", ")))
+		}
+
+		if errorCode.Message == "" {
+			report("", "Missing 'message' field")
+		}
+		for _, message := range validateMessage(errorCode.Message) {
+			report("message", message)
+		}
+	}
+	return diagnostics
+}
+
+// validateMessage checks the placeholders of a message, which become the parameters of the generated constructor
+func validateMessage(message string) []string {
+	var messages []string
+	if strings.ContainsAny(placeholderPattern.ReplaceAllString(message, ""), "{}") {
+		messages = append(messages, "Message must not contain braces other than the {parameter} placeholders")
+	}
+	for _, parameter := range MessageParameters(message) {
+		if !parameterNamePattern.MatchString(parameter) || token.IsKeyword(parameter) || parameter == "err" {
+			messages = append(messages, fmt.Sprintf("Placeholder '{%s}' must be a Go identifier that starts with a lowercase letter and is not a keyword or 'err'", parameter))
+		}
+	}
+	return messages
+}
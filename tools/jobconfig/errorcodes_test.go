@@ -0,0 +1,166 @@
+package jobconfig
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestParseErrorCatalog tests the diagnostics of an error catalog and their positions
+func TestParseErrorCatalog(t *testing.T) {
+	config := &JobConfig{JobEnvVariables: []JobEnvVariable{
+		{Name: "SMIN_ERR_ROLE", Value: "type:enum[Missing|Locked], required:true"},
+		{Name: "SMOUT_TOKEN", Value: "type:string, required:true"},
+	}}
+	testCases := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "Valid catalog",
+			data: `{
+  "$schema": "../tools/error_codes.schema.json",
+  "error_codes": [
+    {"code": "ERR10001", "name": "RoleNotCreated", "category": "upstream", "message": "cannot create role {role}: {error}", "retryable": true},
+    {"code": "ERR10002", "name": "LoginInvalid", "category": "auth", "message": "the login is invalid", "remediation": "rotate the login"}
+  ]
+}`,
+		},
+		{
+			name: "Empty catalog",
+			data: `{}`,
+		},
+		{
+			name: "Invalid JSON",
+			data: `{
+  "error_codes": [
+    {"code": "ERR10001",}
+  ]
+}`,
+			expected: []string{"3:25: Invalid JSON: invalid character '}' looking for beginning of object key string"},
+		},
+		{
+			name: "Unknown fields",
+			data: `{
+  "error_codes": [
+    {"code": "ERR10001", "name": "RoleNotCreated", "category": "upstream", "message": "failed", "status": 500}
+  ],
+  "version": 1
+}`,
+			expected: []string{
+				"3:97: Unknown field 'status'. Only 'code', 'name', 'category', 'message', 'remediation' and 'retryable' are accepted",
+				"5:3: Unknown field 'version'. Only '$schema' and 'error_codes' are accepted",
+			},
+		},
+		{
+			name: "Invalid fields",
+			data: `{
+  "error_codes": [
+    {
+      "code": "ERR11001",
+      "name": "roleNotCreated",
+      "category": "network",
+      "message": "cannot create {Role} in {region"
+    },
+    {
+      "code": "ERR10001",
+      "name": "SecretUnavailable",
+      "category": "upstream",
+      "message": "{err} {type}"
+    }
+  ]
+}`,
+			expected: []string{
+				"4:15: Error code 'ERR11001': Code must be between ERR10000 and ERR10999. The codes from ERR11000 are reserved for the generated code",
+				"5:15: Error code 'ERR11001': Name must be a Go identifier that starts with an uppercase letter, e.g. RoleNotCreated",
+				"6:19: Error code 'ERR11001': Category must be one of: config, auth, upstream, internal",
+				"7:18: Error code 'ERR11001': Message must not contain braces other than the {parameter} placeholders",
+				"7:18: Error code 'ERR11001': Placeholder '{Role}' must be a Go identifier that starts with a lowercase letter and is not a keyword or 'err'",
+				"11:15: Error code 'ERR10001': Name 'SecretUnavailable' is already used by another error code",
+				"13:18: Error code 'ERR10001': Placeholder '{err}' must be a Go identifier that starts with a lowercase letter and is not a keyword or 'err'",
+				"13:18: Error code 'ERR10001': Placeholder '{type}' must be a Go identifier that starts with a lowercase letter and is not a keyword or 'err'",
+			},
+		},
+		{
+			name: "Names of generated identifiers",
+			data: `{
+  "error_codes": [
+    {"code": "ERR10001", "name": "Task", "category": "internal", "message": "failed"},
+    {"code": "ERR10002", "name": "RoleMissing", "category": "config", "message": "failed"},
+    {"code": "ERR10003", "name": "Role", "category": "config", "message": "failed"}
+  ]
+}`,
+			expected: []string{
+				"3:34: Error code 'ERR10001': Name 'Task' names the Go identifier 'NewTaskError', which conflicts with a Go identifier declared by the generated code",
+				"4:34: Error code 'ERR10002': Name 'RoleMissing' names the Go identifier 'ErrRoleMissing', which conflicts with a Go identifier generated for variable 'SMIN_ERR_ROLE'",
+				"5:34: Error code 'ERR10003': Name 'Role' names the Go identifier 'ErrRole', which conflicts with a Go identifier generated for variable 'SMIN_ERR_ROLE'",
+			},
+		},
+		{
+			name: "Missing and duplicate fields",
+			data: `{
+  "error_codes": [
+    {"code": "ERR10001", "name": "RoleNotCreated", "category": "upstream", "message": "failed"},
+    {"code": "ERR10001", "name": "RoleNotCreated", "category": "upstream", "message": "failed"},
+    {"code": "ERR10002", "category": "upstream"},
+    {"name": "RoleNotDeleted", "category": "upstream", "message": "failed"}
+  ]
+}`,
+			expected: []string{
+				"4:14: Error code 'ERR10001': Code is defined more than once",
+				"4:34: Error code 'ERR10001': Name 'RoleNotCreated' is already used by another error code",
+				"5:5: Error code 'ERR10002': Missing 'name' field",
+				"5:5: Error code 'ERR10002': Missing 'message' field",
+				"6:5: Missing 'code' field for the error code at index 3",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diagnostics := ParseErrorCatalog([]byte(tc.data), config)
+
+			if actual := diagnosticMessages(diagnostics); !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected diagnostics %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestMessageFormat tests the parameters and the format string of the generated constructor of a message
+func TestMessageFormat(t *testing.T) {
+	testCases := []struct {
+		message            string
+		expectedParameters []string
+		expectedFormat     string
+	}{
+		{
+			message:        "the login is invalid",
+			expectedFormat: "the login is invalid",
+		},
+		{
+			message:            "cannot create role {role} in {database}: {error}",
+			expectedParameters: []string{"role", "database", "error"},
+			expectedFormat:     "cannot create role %s in %s: %w",
+		},
+		{
+			message:            "role {role} already exists, choose another name than {role}",
+			expectedParameters: []string{"role"},
+			expectedFormat:     "role %s already exists, choose another name than %s",
+		},
+		{
+			message:            "quota at 100% for {account}",
+			expectedParameters: []string{"account"},
+			expectedFormat:     "quota at 100%% for %s",
+		},
+	}
+
+	for _, tc := range testCases {
+		if actual := MessageParameters(tc.message); !slices.Equal(actual, tc.expectedParameters) {
+			t.Errorf("MessageParameters(%q) = %q, expected %q", tc.message, actual, tc.expectedParameters)
+		}
+		if actual := MessageFormat(tc.message); actual != tc.expectedFormat {
+			t.Errorf("MessageFormat(%q) = %q, expected %q", tc.message, actual, tc.expectedFormat)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package jobconfig parses and validates the job_config.json file of a credentials provider.
+// It implements the rules of the contract with Secrets Manager once, for the job code generator
+// and, through the "validate" subcommand of the generator, for the job deployer.
+package jobconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// JobEnvVariable represents a single environment variable entry.
+type JobEnvVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// JobConfig represents the user input job configuration.
+type JobConfig struct {
+	JobEnvVariables []JobEnvVariable `json:"job_env_variables"`
+}
+
+// Position is a 1-based line and column in the job configuration file. The column is counted in bytes.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Diagnostic describes a problem found in the job configuration file
+type Diagnostic struct {
+	Position
+	Variable string // The name of the variable that the problem was found in, empty for the whole file
+	Message  string
+}
+
+// String formats the diagnostic as "line:column: message"
+func (d Diagnostic) String() string {
+	if d.Variable != "" {
+		return fmt.Sprintf("%d:%d: Variable '%s': %s", d.Line, d.Column, d.Variable, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+}
+
+// Parse parses and validates the content of a job configuration file.
+// The configuration is valid if no diagnostics are returned. Otherwise, it is nil if the content is not valid JSON.
+func Parse(data []byte) (*JobConfig, []Diagnostic) {
+	var config JobConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, []Diagnostic{jsonErrorDiagnostic(data, err)}
+	}
+
+	layout, diagnostics := locate(data)
+	diagnostics = append(diagnostics, validate(&config, layout)...)
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return &config, diagnostics
+}
+
+// jsonErrorDiagnostic converts a JSON decoding error to a diagnostic at the offset of the error
+func jsonErrorDiagnostic(data []byte, err error) Diagnostic {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset of a syntax error is after the offending character
+		return Diagnostic{Position: positionAt(data, int(syntaxErr.Offset)-1), Message: fmt.Sprintf("Invalid JSON: %v", err)}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		message := fmt.Sprintf("Invalid JSON value: expected %s but found %s", typeErr.Type, typeErr.Value)
+		if typeErr.Field != "" {
+			message = fmt.Sprintf("Invalid JSON value for '%s': expected %s but found %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		// The offset of a type error is at the end of the offending value
+		return Diagnostic{Position: positionAt(data, int(typeErr.Offset)), Message: message}
+	}
+	return Diagnostic{Position: Position{Line: 1, Column: 1}, Message: fmt.Sprintf("Invalid JSON: %v", err)}
+}
+
+// positionAt converts a byte offset in the data to a position
+func positionAt(data []byte, offset int) Position {
+	offset = min(max(offset, 0), len(data))
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return Position{Line: line, Column: column}
+}
+
+// fileLayout holds the positions of the elements of a job configuration file
+type fileLayout struct {
+	variables       Position // The job_env_variables array, or the start of the file if it is missing
+	variableLayouts []variableLayout
+}
+
+// variableLayout holds the positions of a variable object and of its name and value.
+// The name and value positions are those of the object if the fields are missing.
+type variableLayout struct {
+	object Position
+	name   Position
+	value  Position
+}
+
+// variable returns the layout of the variable at the given index
+func (l fileLayout) variable(index int) variableLayout {
+	if index < len(l.variableLayouts) {
+		return l.variableLayouts[index]
+	}
+	return variableLayout{object: l.variables, name: l.variables, value: l.variables}
+}
+
+// locate walks the JSON tokens of a job configuration file that was decoded successfully to find the positions
+// of its elements. It reports the fields that are not part of the job configuration format.
+func locate(data []byte) (fileLayout, []Diagnostic) {
+	s := &tokenScanner{data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	layout := fileLayout{variables: Position{Line: 1, Column: 1}}
+	var diagnostics []Diagnostic
+
+	if token, _ := s.next(); token != json.Delim('{') {
+		return layout, nil
+	}
+	for s.decoder.More() && s.err == nil {
+		key, keyPosition := s.next()
+		switch key {
+		case "$schema":
+			s.skip()
+		case "job_env_variables":
+			token, position := s.next()
+			layout.variables = position
+			if token != json.Delim('[') {
+				continue
+			}
+			for s.decoder.More() && s.err == nil {
+				layout.variableLayouts = append(layout.variableLayouts, s.locateVariable(&diagnostics))
+			}
+			s.next()
+		default:
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: keyPosition,
+				Message:  fmt.Sprintf("Unknown field '%v'. Only '$schema' and 'job_env_variables' are accepted", key),
+			})
+			s.skip()
+		}
+	}
+	return layout, diagnostics
+}
+
+// tokenScanner reads the JSON tokens of a file along with their positions
+type tokenScanner struct {
+	data    []byte
+	decoder *json.Decoder
+	err     error // The error that stopped the scan, e.g. io.EOF at the end of the data
+}
+
+// next returns the next token and its position. The token is nil for a JSON null and once the scan stopped.
+func (s *tokenScanner) next() (json.Token, Position) {
+	// The decoder offset is at the end of the previous token, before the separators of the next one
+	offset := int(s.decoder.InputOffset())
+	for offset < len(s.data) && bytes.IndexByte([]byte(" \t\r\n:,"), s.data[offset]) >= 0 {
+		offset++
+	}
+	token, err := s.decoder.Token()
+	if err != nil {
+		s.err = err
+		return nil, positionAt(s.data, offset)
+	}
+	return token, positionAt(s.data, offset)
+}
+
+// skip reads the next value, including the nested values of an object or an array
+func (s *tokenScanner) skip() {
+	depth := 0
+	for {
+		token, _ := s.next()
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 || s.err != nil {
+			return
+		}
+	}
+}
+
+// locateVariable reads a variable object and returns the positions of its fields
+func (s *tokenScanner) locateVariable(diagnostics *[]Diagnostic) variableLayout {
+	token, position := s.next()
+	layout := variableLayout{object: position, name: position, value: position}
+	if token != json.Delim('{') {
+		return layout
+	}
+	for s.decoder.More() && s.err == nil {
+		key, keyPosition := s.next()
+		switch key {
+		case "name":
+			_, layout.name = s.next()
+		case "value":
+			_, layout.value = s.next()
+		default:
+			*diagnostics = append(*diagnostics, Diagnostic{
+				Position: keyPosition,
+				Message:  fmt.Sprintf("Unknown field '%v'. Only 'name' and 'value' are accepted", key),
+			})
+			s.skip()
+		}
+	}
+	s.next()
+	return layout
+}
@@ -0,0 +1,119 @@
+package jobconfig
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestParseDiagnostics tests the line and column of the diagnostics, which point to the offending element of the file
+func TestParseDiagnostics(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "Valid configuration",
+			data: `{
+  "$schema": "../tools/job_config.schema.json",
+  "job_env_variables": [
+    {"name": "SMOUT_TOKEN", "value": "type:string, required:true"}
+  ]
+}`,
+		},
+		{
+			name: "Invalid JSON",
+			data: `{
+  "job_env_variables": [
+    {"name": "SMOUT_TOKEN" "value": "type:string, required:true"}
+  ]
+}`,
+			expected: []string{"3:28: Invalid JSON: invalid character '\"' after object key:value pair"},
+		},
+		{
+			name: "Invalid JSON value",
+			data: `{
+  "job_env_variables": "SMOUT_TOKEN"
+}`,
+			expected: []string{"2:37: Invalid JSON value for 'job_env_variables': expected []jobconfig.JobEnvVariable but found string"},
+		},
+		{
+			name:     "Truncated JSON",
+			data:     "{\n  \"job_env_variables\": [",
+			expected: []string{"2:24: Invalid JSON: unexpected end of JSON input"},
+		},
+		{
+			name: "Unknown fields",
+			data: `{
+  "job_env_variables": [
+    {"name": "SMOUT_TOKEN", "value": "type:string, required:true", "default": "x"}
+  ],
+  "version": 1
+}`,
+			expected: []string{
+				"3:68: Unknown field 'default'. Only 'name' and 'value' are accepted",
+				"5:3: Unknown field 'version'. Only '$schema' and 'job_env_variables' are accepted",
+			},
+		},
+		{
+			name: "No variables",
+			data: `{
+  "job_env_variables": []
+}`,
+			expected: []string{"2:24: Job configuration file does not define any variables in 'job_env_variables'"},
+		},
+		{
+			name:     "Missing variables",
+			data:     `{}`,
+			expected: []string{"1:1: Job configuration file does not define any variables in 'job_env_variables'"},
+		},
+		{
+			name: "Diagnostics of the name, the value and the object of the variables",
+			data: `{
+  "job_env_variables": [
+    {
+      "name": "SMOUT_TOKEN",
+      "value": "type:string, required:true"
+    },
+    {
+      "name": "smin_user",
+      "value": "type:text"
+    },
+    {
+      "name": "SMOUT_TOKEN",
+      "value": "type:string"
+    },
+    {
+      "name": "SMIN_SCOPE"
+    }
+  ]
+}`,
+			expected: []string{
+				"8:15: Variable 'smin_user': Variable name must start with 'SMIN_' or 'SMOUT_'",
+				"8:15: Variable 'smin_user': Variable name should only contain uppercase letters, numbers, and underscores",
+				"9:16: Variable 'smin_user': Invalid type 'text'. Must be one of: string, integer, boolean, secret_id, string_list, json, duration, url, or enum[options]",
+				"12:15: Variable 'SMOUT_TOKEN': Variable is defined more than once",
+				"15:5: Variable 'SMIN_SCOPE': Missing 'value' field",
+			},
+		},
+		{
+			name: "No required output",
+			data: `{
+  "job_env_variables": [
+    {"name": "SMOUT_TOKEN", "value": "type:string, required:false"}
+  ]
+}`,
+			expected: []string{"2:24: At least one 'SMOUT_' variable with attribute 'required:true' must be defined"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diagnostics := Parse([]byte(tc.data))
+
+			if actual := diagnosticMessages(diagnostics); !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected diagnostics %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package jobconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namePattern is the format of the variable names
+var namePattern = regexp.MustCompile(`^(SMIN_|SMOUT_)[A-Z0-9_]+$`)
+
+// validTypes are the attribute types other than enum[...]
+var validTypes = map[string]bool{
+	"string":      true,
+	"integer":     true,
+	"boolean":     true,
+	"secret_id":   true,
+	"string_list": true,
+	"json":        true,
+	"duration":    true,
+	"url":         true,
+}
+
+// inputOnlyTypes are the types that Secrets Manager passes as strings and the generated code parses.
+// They are only supported for 'SMIN_' variables.
+var inputOnlyTypes = map[string]bool{
+	"string_list": true,
+	"duration":    true,
+	"url":         true,
+}
+
+// validate validates the job configuration according to the contract with Secrets Manager.
+// The diagnostics are positioned with the layout of the file.
+func validate(config *JobConfig, layout fileLayout) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	if len(config.JobEnvVariables) == 0 {
+		return []Diagnostic{{Position: layout.variables, Message: "Job configuration file does not define any variables in 'job_env_variables'"}}
+	}
+
+	seenNames := make(map[string]bool)
+	seenIdentifiers := make(map[string]string) // The variables of the generated Go identifiers, keyed by identifier
+	requiredOutputVariableFound := false
+	for i, envVar := range config.JobEnvVariables {
+		variableLayout := layout.variable(i)
+		if envVar.Name == "" {
+			diagnostics = append(diagnostics, Diagnostic{
+				Position: variableLayout.object,
+				Message:  fmt.Sprintf("Missing 'name' field for the variable at index %d", i),
+			})
+			continue
+		}
+		if seenNames[envVar.Name] {
+			diagnostics = append(diagnostics, Diagnostic{Position: variableLayout.name, Variable: envVar.Name, Message: "Variable is defined more than once"})
+		}
+		seenNames[envVar.Name] = true
+		if envVar.Value == "" {
+			diagnostics = append(diagnostics, Diagnostic{Position: variableLayout.object, Variable: envVar.Name, Message: "Missing 'value' field"})
+			continue
+		}
+
+		for _, message := range validateName(envVar.Name) {
+			diagnostics = append(diagnostics, Diagnostic{Position: variableLayout.name, Variable: envVar.Name, Message: message})
+		}
+		for _, message := range validateValue(envVar.Name, envVar.Value) {
+			diagnostics = append(diagnostics, Diagnostic{Position: variableLayout.value, Variable: envVar.Name, Message: message})
+		}
+
+		if _, validations, err := ParseAttributes(envVar.Value); err == nil && strings.HasPrefix(envVar.Name, "SMOUT_") && validations["required"] == "true" {
+			requiredOutputVariableFound = true
+		}
+		for _, message := range validateIdentifiers(envVar.Name, envVar.Value, seenIdentifiers) {
+			diagnostics = append(diagnostics, Diagnostic{Position: variableLayout.value, Variable: envVar.Name, Message: message})
+		}
+	}
+
+	if !requiredOutputVariableFound {
+		diagnostics = append(diagnostics, Diagnostic{
+			Position: layout.variables,
+			Message:  "At least one 'SMOUT_' variable with attribute 'required:true' must be defined",
+		})
+	}
+	return diagnostics
+}
+
+// validateName checks the format of a variable name
+func validateName(name string) []string {
+	var messages []string
+
+	// Check prefix
+	if !strings.HasPrefix(name, "SMIN_") && !strings.HasPrefix(name, "SMOUT_") {
+		messages = append(messages, "Variable name must start with 'SMIN_' or 'SMOUT_'")
+	}
+
+	// Check variable name format (no lowercase, no spaces)
+	if !namePattern.MatchString(name) {
+		messages = append(messages, "Variable name should only contain uppercase letters, numbers, and underscores")
+	}
+
+	// Check that the Config field of an input does not conflict with a generated field
+	if fieldName := "SM_" + strings.TrimPrefix(name, "SMIN_"); strings.HasPrefix(name, "SMIN_") && reservedFieldNames[fieldName] {
+		messages = append(messages, fmt.Sprintf("Variable name conflicts with the generated Config field %s", fieldName))
+	}
+	return messages
+}
+
+// validateValue checks the attributes of a variable value
+func validateValue(name, value string) []string {
+	var messages []string
+
+	// Validate value attributes
+	attrType, validations, err := ParseAttributes(value)
+	if err != nil {
+		return []string{fmt.Sprintf("Invalid attribute format: %v", err)}
+	}
+
+	// Check if type is specified
+	if attrType == "" {
+		messages = append(messages, "Variable value must specify a 'type' attribute")
+	} else if !validTypes[attrType] && !strings.HasPrefix(attrType, "enum[") {
+		// Check if type is valid
+		messages = append(messages, fmt.Sprintf("Invalid type '%s'. Must be one of: string, integer, boolean, secret_id, string_list, json, duration, url, or enum[options]", attrType))
+	} else if inputOnlyTypes[attrType] && !strings.HasPrefix(name, "SMIN_") {
+		messages = append(messages, fmt.Sprintf("Type '%s' is only supported for 'SMIN_' variables", attrType))
+	}
+
+	// Check enum format
+	if strings.HasPrefix(attrType, "enum[") {
+		if !strings.HasSuffix(attrType, "]") || len(attrType) <= 6 {
+			messages = append(messages, "Invalid enum format. Must be in format 'enum[optionA|optionB|...]'")
+		} else {
+			// Check if enum options are properly formatted
+			options := attrType[5 : len(attrType)-1] // Remove 'enum[' prefix and ']' suffix
+			if options == "" || !strings.Contains(options, "|") {
+				messages = append(messages, "Enum must have at least two options separated by '|'")
+			} else if msg := validateEnumOptions(name, EnumOptions(attrType)); msg != "" {
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	// Check if 'required' attribute value is valid
+	if reqVal, ok := validations["required"]; ok {
+		if reqVal != "true" && reqVal != "false" {
+			messages = append(messages, "Required attribute must be 'true' or 'false'")
+		}
+	}
+
+	// Check if 'default' attribute value is valid
+	if defaultVal, ok := validations["default"]; ok {
+		if msg := validateDefaultValue(name, attrType, validations["required"], defaultVal); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+
+	// Check if there are invalid attributes
+	for _, key := range slices.Sorted(maps.Keys(validations)) {
+		if !supportedAttributes[key] {
+			messages = append(messages, fmt.Sprintf("Invalid attribute '%s'. Only 'type', 'required', 'default', 'min', 'max', 'minlen', 'maxlen', 'pattern', 'secret_types', 'field' and 'description' attributes are accepted", key))
+		}
+	}
+
+	// Check if range, length and pattern attributes are valid
+	messages = append(messages, validateConstraints(name, attrType, validations)...)
+
+	// Check if the attributes of the secret resolver are valid
+	messages = append(messages, validateSecretAttributes(name, attrType, validations)...)
+
+	return messages
+}
+
+// validateSecretAttributes checks the 'secret_types' and 'field' attributes, which configure the resolver of a secret_id input variable
+func validateSecretAttributes(name, attrType string, validations map[string]string) []string {
+	secretTypes, hasSecretTypes := validations["secret_types"]
+	_, hasField := validations["field"]
+	if !hasSecretTypes && !hasField {
+		return nil
+	}
+	if attrType != "secret_id" || !strings.HasPrefix(name, "SMIN_") {
+		return []string{"Attributes 'secret_types' and 'field' are only supported for 'SMIN_' variables of type 'secret_id'"}
+	}
+
+	var messages []string
+	seenTypes := make(map[string]bool)
+	for _, secretType := range SecretTypeOptions(secretTypes) {
+		if !slices.Contains(SecretTypes, secretType) {
+			messages = append(messages, fmt.Sprintf("Invalid secret type '%s' in 'secret_types'. Must be one of: %s", secretType, strings.Join(SecretTypes, ", ")))
+		} else if seenTypes[secretType] {
+			messages = append(messages, fmt.Sprintf("Secret type '%s' is defined more than once in 'secret_types'", secretType))
+		}
+		seenTypes[secretType] = true
+	}
+	return messages
+}
+
+// validateDefaultValue checks that a default value is declared on an optional input variable and matches its type
+func validateDefaultValue(name, attrType, required, defaultVal string) string {
+	if !strings.HasPrefix(name, "SMIN_") {
+		return "Default values are only supported for 'SMIN_' variables"
+	}
+	if required == "true" {
+		return "Default values are only supported for variables with 'required:false'"
+	}
+
+	switch {
+	case attrType == "integer":
+		if _, err := strconv.Atoi(defaultVal); err != nil {
+			return fmt.Sprintf("Default value '%s' is not a valid integer", defaultVal)
+		}
+	case attrType == "boolean":
+		if defaultVal != "true" && defaultVal != "false" {
+			return fmt.Sprintf("Default value '%s' is not a valid boolean. Must be 'true' or 'false'", defaultVal)
+		}
+	case IsEnumType(attrType):
+		if !slices.Contains(EnumOptions(attrType), defaultVal) {
+			return fmt.Sprintf("Default value '%s' is not one of the enum options: %s", defaultVal, strings.Join(EnumOptions(attrType), ", "))
+		}
+	case attrType == "json":
+		if !json.Valid([]byte(defaultVal)) {
+			return fmt.Sprintf("Default value '%s' is not a valid JSON document", defaultVal)
+		}
+	case attrType == "duration":
+		if _, err := time.ParseDuration(defaultVal); err != nil {
+			return fmt.Sprintf("Default value '%s' is not a valid duration, e.g. 90s or 1h30m", defaultVal)
+		}
+	case attrType == "url":
+		if parsed, err := url.Parse(defaultVal); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Sprintf("Default value '%s' is not an absolute URL, e.g. https://example.com", defaultVal)
+		}
+	}
+	return ""
+}
+
+// validateIdentifiers checks that the Go identifiers generated for an input variable, i.e. the type, constants and
+// parse function of an enum and the resolver of a secret_id, do not conflict with the identifiers declared by the
+// templates or generated for the input variables before it, e.g. KeyAlgoRSA for SMIN_KEY with option Algo_RSA and
+// for SMIN_KEY_ALGO with option RSA. The identifiers of the variable are added to seenIdentifiers.
+func validateIdentifiers(name, value string, seenIdentifiers map[string]string) []string {
+	attrType, _, err := ParseAttributes(value)
+	if err != nil || !strings.HasPrefix(name, "SMIN_") {
+		return nil
+	}
+	var messages []string
+	check := func(kind, identifier string) {
+		if isReservedIdentifier(identifier) {
+			messages = append(messages, fmt.Sprintf("%s conflicts with a Go identifier declared by the generated code", kind))
+		} else if other, ok := seenIdentifiers[identifier]; ok && other != name {
+			messages = append(messages, fmt.Sprintf("%s conflicts with a Go identifier generated for variable '%s'", kind, other))
+		}
+		seenIdentifiers[identifier] = name
+	}
+
+	switch {
+	case IsEnumType(attrType):
+		typeName := EnumTypeName(name)
+		check(fmt.Sprintf("Enum type name '%s'", typeName), typeName)
+		check(fmt.Sprintf("Enum parse function '%s'", "Parse"+typeName), "Parse"+typeName)
+		seenConstants := make(map[string]bool)
+		for _, option := range EnumOptions(attrType) {
+			// The duplicate constants of a variable are reported by validateEnumOptions
+			if constName := EnumConstName(typeName, option); !seenConstants[constName] {
+				seenConstants[constName] = true
+				check(fmt.Sprintf("Enum option '%s' maps to the Go constant '%s', which", option, constName), constName)
+			}
+		}
+	case attrType == "secret_id":
+		resolverName := SecretResolverName(name)
+		check(fmt.Sprintf("Secret resolver name '%s'", resolverName), resolverName)
+	}
+	return messages
+}
+
+// generatedIdentifiers returns the variables of the Go identifiers generated for the input variables of a job
+// configuration, keyed by identifier
+func generatedIdentifiers(config *JobConfig) map[string]string {
+	identifiers := make(map[string]string)
+	for _, envVar := range config.JobEnvVariables {
+		validateIdentifiers(envVar.Name, envVar.Value, identifiers)
+	}
+	return identifiers
+}
+
+// validateEnumOptions checks that the enum options are not empty, unique, and map to unique Go constant names
+func validateEnumOptions(name string, options []string) string {
+	typeName := EnumTypeName(name)
+	seenOptions := make(map[string]bool)
+	seenConstants := make(map[string]string)
+	for _, option := range options {
+		if option == "" {
+			return "Enum options cannot be empty"
+		}
+		if strings.HasPrefix(name, "SMOUT_") && strings.ContainsAny(option, " \t") {
+			// The options of an output are checked with the space-separated 'oneof' validation tag
+			return fmt.Sprintf("Enum option '%s' of an 'SMOUT_' variable cannot contain spaces", option)
+		}
+		if seenOptions[option] {
+			return fmt.Sprintf("Enum option '%s' is defined more than once", option)
+		}
+		seenOptions[option] = true
+		if toCamelCase(option) == "" {
+			return fmt.Sprintf("Enum option '%s' must contain an ASCII letter or digit to name its Go constant", option)
+		}
+
+		constName := EnumConstName(typeName, option)
+		if other, ok := seenConstants[constName]; ok {
+			return fmt.Sprintf("Enum options '%s' and '%s' map to the same Go constant '%s'", other, option, constName)
+		}
+		seenConstants[constName] = option
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package jobconfig
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// jobConfigJSON returns a job configuration with the given variables and a required output, as name-value pairs
+func jobConfigJSON(t *testing.T, nameValues ...string) []byte {
+	t.Helper()
+	config := JobConfig{JobEnvVariables: []JobEnvVariable{{Name: "SMOUT_TOKEN", Value: "type:string, required:true"}}}
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		config.JobEnvVariables = append(config.JobEnvVariables, JobEnvVariable{Name: nameValues[i], Value: nameValues[i+1]})
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// diagnosticMessages returns the formatted diagnostics of a job configuration
+func diagnosticMessages(diagnostics []Diagnostic) []string {
+	var messages []string
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	return messages
+}
+
+// TestParseEnumOptions tests the diagnostics of the enum options, which name the generated Go constants
+func TestParseEnumOptions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		nameValues    []string
+		expectedError string // A substring of the only expected diagnostic, empty if the configuration is valid
+	}{
+		{
+			name:       "Valid options",
+			nameValues: []string{"SMIN_KEY_ALGO", "type:enum[RSA|ECDSA]"},
+		},
+		{
+			name:          "Single option",
+			nameValues:    []string{"SMIN_KEY_ALGO", "type:enum[RSA]"},
+			expectedError: "Enum must have at least two options separated by '|'",
+		},
+		{
+			name:          "Duplicate option",
+			nameValues:    []string{"SMIN_KEY_ALGO", "type:enum[RSA|RSA]"},
+			expectedError: "Enum option 'RSA' is defined more than once",
+		},
+		{
+			name:          "Options with the same constant",
+			nameValues:    []string{"SMIN_KEY_ALGO", "type:enum[rsa-2048|rsa_2048]"},
+			expectedError: "Enum options 'rsa-2048' and 'rsa_2048' map to the same Go constant 'KeyAlgoRsa2048'",
+		},
+		{
+			name:          "Option without ASCII letters or digits",
+			nameValues:    []string{"SMIN_KEY_ALGO", "type:enum[-|+]"},
+			expectedError: "Enum option '-' must contain an ASCII letter or digit to name its Go constant",
+		},
+		{
+			name:          "Non-ASCII option",
+			nameValues:    []string{"SMIN_REGION", "type:enum[zürich|ö]"},
+			expectedError: "Enum option 'ö' must contain an ASCII letter or digit to name its Go constant",
+		},
+		{
+			name:          "Type name declared by the generated code",
+			nameValues:    []string{"SMIN_CONFIG", "type:enum[A|B]"},
+			expectedError: "Enum type name 'Config' conflicts with a Go identifier declared by the generated code",
+		},
+		{
+			name:          "Type name of the error catalog",
+			nameValues:    []string{"SMIN_ERROR_CATALOG", "type:enum[A|B], required:false"},
+			expectedError: "Enum type name 'ErrorCatalog' conflicts with a Go identifier declared by the generated code",
+		},
+		{
+			name:          "Constant declared by the generated code",
+			nameValues:    []string{"SMIN_TASK", "type:enum[Hook|None]"},
+			expectedError: "Enum option 'Hook' maps to the Go constant 'TaskHook', which conflicts with a Go identifier declared by the generated code",
+		},
+		{
+			name:          "Constant of a builtin error code",
+			nameValues:    []string{"SMIN_ERR_JOB", "type:enum[Timeout|None]"},
+			expectedError: "Enum option 'Timeout' maps to the Go constant 'ErrJobTimeout', which conflicts with a Go identifier declared by the generated code",
+		},
+		{
+			name:          "Constant of another variable",
+			nameValues:    []string{"SMIN_KEY_ALGO", "type:enum[RSA|ECDSA]", "SMIN_KEY", "type:enum[Algo_RSA|None]"},
+			expectedError: "Variable 'SMIN_KEY': Enum option 'Algo_RSA' maps to the Go constant 'KeyAlgoRSA', which conflicts with a Go identifier generated for variable 'SMIN_KEY_ALGO'",
+		},
+		{
+			name:          "Type name of another variable",
+			nameValues:    []string{"SMIN_KEY", "type:enum[Algo|None]", "SMIN_KEY_ALGO", "type:enum[RSA|ECDSA]"},
+			expectedError: "Variable 'SMIN_KEY_ALGO': Enum type name 'KeyAlgo' conflicts with a Go identifier generated for variable 'SMIN_KEY'",
+		},
+		{
+			name:          "Parse function of another variable",
+			nameValues:    []string{"SMIN_KEY", "type:enum[A|B]", "SMIN_PARSE_KEY", "type:enum[A|B]"},
+			expectedError: "Variable 'SMIN_PARSE_KEY': Enum type name 'ParseKey' conflicts with a Go identifier generated for variable 'SMIN_KEY'",
+		},
+		{
+			name:          "Secret resolver of another variable",
+			nameValues:    []string{"SMIN_LOGIN", "type:secret_id", "SMIN_RESOLVE_LOGIN", "type:enum[A|B]"},
+			expectedError: "Variable 'SMIN_RESOLVE_LOGIN': Enum type name 'ResolveLogin' conflicts with a Go identifier generated for variable 'SMIN_LOGIN'",
+		},
+		{
+			name:       "Output types are not generated",
+			nameValues: []string{"SMOUT_CONFIG", "type:enum[A|B]"},
+		},
+		{
+			name:       "Output options are not constants",
+			nameValues: []string{"SMIN_KEY_ALGO", "type:enum[RSA|ECDSA]", "SMOUT_KEY", "type:enum[ALGO_RSA|NONE]"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diagnostics := Parse(jobConfigJSON(t, tc.nameValues...))
+
+			messages := diagnosticMessages(diagnostics)
+			if tc.expectedError == "" {
+				if len(messages) > 0 {
+					t.Errorf("expected no diagnostics, got %q", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || !strings.Contains(messages[0], tc.expectedError) {
+				t.Errorf("expected a diagnostic containing %q, got %q", tc.expectedError, messages)
+			}
+		})
+	}
+}
+
+// TestEnumConstName tests that the constant names are built from the ASCII letters and digits of the options
+func TestEnumConstName(t *testing.T) {
+	testCases := []struct {
+		typeName string
+		option   string
+		expected string
+	}{
+		{typeName: "KeyAlgo", option: "RSA", expected: "KeyAlgoRSA"},
+		{typeName: "KeyAlgo", option: "rsa-2048", expected: "KeyAlgoRsa2048"},
+		{typeName: "Trigger", option: "secret_creation", expected: "TriggerSecretCreation"},
+		{typeName: "Region", option: "zürich", expected: "RegionZRich"},
+		{typeName: "KeyAlgo", option: "-", expected: "KeyAlgo"},
+	}
+
+	for _, tc := range testCases {
+		if actual := EnumConstName(tc.typeName, tc.option); actual != tc.expected {
+			t.Errorf("EnumConstName(%q, %q) = %q, expected %q", tc.typeName, tc.option, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"job-code-generator/jobconfig"
+)
+
+// Marker comments that delimit the generated variable tables in a provider README
+const (
+	variableTablesBeginMarker = "<!-- BEGIN GENERATED VARIABLE TABLES -->"
+	variableTablesEndMarker   = "<!-- END GENERATED VARIABLE TABLES -->"
+)
+
+// runReadme implements the "readme" subcommand, which refreshes the variable tables and the error code table
+// between the marker comments of a provider README from its job_config.json and error_codes.json
+func runReadme(args []string) {
+	readmeFlags := flag.NewFlagSet("readme", flag.ExitOnError)
+	jobDir := readmeFlags.String("jobdir", "", "Path to the job project directory")
+	readmePath := readmeFlags.String("readme", "", "Path to the README file to update (default: <jobdir>/README.md)")
+	verify := readmeFlags.Bool("verify", false, "Check that the README tables are up to date instead of writing them")
+	readmeFlags.Parse(args)
+
+	if *jobDir == "" {
+		fmt.Println("Usage: secrets-manager-job-generator readme -jobdir=<job_directory> [-readme=<readme_file>] [-verify]")
+		os.Exit(1)
+	}
+	if *readmePath == "" {
+		*readmePath = filepath.Join(*jobDir, "README.md")
+	}
+
+	userSchema, commonJobConfig := loadJobConfig(filepath.Join(*jobDir, "job_config.json"), false)
+	catalogPath := filepath.Join(*jobDir, errorCatalogFileName)
+	catalog := loadErrorCatalog(catalogPath, userSchema)
+
+	readme, err := os.ReadFile(*readmePath)
+	if err != nil {
+		fmt.Printf("Error reading README file: %v\n", err)
+		os.Exit(1)
+	}
+	updated, err := ReplaceVariableTables(string(readme), RenderVariableTables(commonJobConfig, userSchema))
+	if err != nil {
+		fmt.Printf("Error updating %s: %v\n", *readmePath, err)
+		os.Exit(1)
+	}
+	// The error code table is optional, unless the job declares its own error codes
+	if _, statErr := os.Stat(catalogPath); statErr == nil || strings.Contains(updated, errorCodeTableBeginMarker) {
+		updated, err = ReplaceErrorCodeTable(updated, RenderErrorCodeTable(jobErrorCodes(userSchema, catalog)))
+		if err != nil {
+			fmt.Printf("Error updating %s: %v\n", *readmePath, err)
+			os.Exit(1)
+		}
+	}
+
+	if *verify {
+		if !verifyGeneratedFile(*readmePath, updated) {
+			os.Exit(1)
+		}
+		fmt.Printf("Tables in %s are up to date.\n", *readmePath)
+		return
+	}
+
+	if err := os.WriteFile(*readmePath, []byte(updated), 0644); err != nil {
+		fmt.Printf("Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Tables in %s updated successfully.\n", *readmePath)
+}
+
+// markVariableTables wraps the variable tables in the marker comments
+func markVariableTables(tables string) string {
+	return variableTablesBeginMarker + "\n\n" + tables + "\n" + variableTablesEndMarker
+}
+
+// ReplaceVariableTables replaces the content between the variable tables marker comments of a README with the given tables
+func ReplaceVariableTables(readme, tables string) (string, error) {
+	if strings.Count(readme, variableTablesBeginMarker) != 1 || strings.Count(readme, variableTablesEndMarker) != 1 {
+		return "", fmt.Errorf("the README must contain the marker comments %s and %s exactly once", variableTablesBeginMarker, variableTablesEndMarker)
+	}
+	begin := strings.Index(readme, variableTablesBeginMarker)
+	end := strings.Index(readme, variableTablesEndMarker)
+	if end < begin {
+		return "", fmt.Errorf("the marker comment %s must come before %s", variableTablesBeginMarker, variableTablesEndMarker)
+	}
+	return readme[:begin] + markVariableTables(tables) + readme[end+len(variableTablesEndMarker):], nil
+}
+
+// RenderVariableTables renders the Markdown tables of the service parameters, the required and optional
+// input variables and the output variables of a job
+func RenderVariableTables(commonJobConfig *CommonJobConfig, userSchema *jobconfig.JobConfig) string {
+	var serviceRows, requiredRows, optionalRows, outputRows [][]string
+
+	for _, envVar := range commonJobConfig.CommonEnvVariables {
+		_, validations, err := jobconfig.ParseAttributes(envVar.Value)
+		if err != nil {
+			continue
+		}
+		serviceRows = append(serviceRows, []string{"`" + envVar.Name + "`", validations["description"]})
+	}
+
+	for _, envVar := range userSchema.JobEnvVariables {
+		attrType, validations, err := jobconfig.ParseAttributes(envVar.Value)
+		if err != nil {
+			continue
+		}
+		name := "`" + envVar.Name + "`"
+		typeName := "`" + attrType + "`"
+		description := validations["description"]
+		switch {
+		case strings.HasPrefix(envVar.Name, "SMOUT_"):
+			outputRows = append(outputRows, []string{name, typeName, description})
+		case validations["required"] == "true":
+			requiredRows = append(requiredRows, []string{name, typeName, description})
+		default:
+			// Without a default value, the variable has the zero value of its type
+			defaultValue := "(empty)"
+			if value, ok := validations["default"]; ok {
+				defaultValue = "`" + value + "`"
+			} else if attrType == "boolean" {
+				defaultValue = "`false`"
+			} else if attrType == "integer" {
+				defaultValue = "`0`"
+			}
+			optionalRows = append(optionalRows, []string{name, typeName, description, defaultValue})
+		}
+	}
+
+	var tablesBuilder strings.Builder
+	tablesBuilder.WriteString("#### Service Parameters\n\n")
+	tablesBuilder.WriteString("The service environment variables that are passed by Secrets Manager to the job:\n\n")
+	writeMarkdownTable(&tablesBuilder, []string{"Environment Variable", "Description"}, serviceRows)
+
+	tablesBuilder.WriteString("\n#### Job Custom Parameters\n\n")
+	tablesBuilder.WriteString("The job custom environment variables are defined in: [job_config.json](./job_config.json)\n\n")
+	tablesBuilder.WriteString("##### Required Parameters\n\n")
+	writeMarkdownTable(&tablesBuilder, []string{"Environment Variable", "Type", "Description"}, requiredRows)
+	tablesBuilder.WriteString("\n##### Optional Parameters\n\n")
+	writeMarkdownTable(&tablesBuilder, []string{"Environment Variable", "Type", "Description", "Default Value"}, optionalRows)
+
+	tablesBuilder.WriteString("\n#### Output Values\n\n")
+	tablesBuilder.WriteString("The job produces these values that are stored in Secrets Manager:\n\n")
+	writeMarkdownTable(&tablesBuilder, []string{"Environment Variable", "Type", "Description"}, outputRows)
+
+	return tablesBuilder.String()
+}
+
+// writeMarkdownTable writes a Markdown table with the columns padded to the width of their widest cell
+func writeMarkdownTable(tableBuilder *strings.Builder, header []string, rows [][]string) {
+	if len(rows) == 0 {
+		tableBuilder.WriteString("None.\n")
+		return
+	}
+
+	// Escape the pipes in the cells, e.g. in enum types, so that they don't split the cell
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	writeRow := func(row []string) {
+		for i, cell := range row {
+			tableBuilder.WriteString(fmt.Sprintf("| %-*s ", widths[i], cell))
+		}
+		tableBuilder.WriteString("|\n")
+	}
+	writeRow(header)
+	for i := range header {
+		tableBuilder.WriteString("|" + strings.Repeat("-", widths[i]+2))
+	}
+	tableBuilder.WriteString("|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
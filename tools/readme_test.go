@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"job-code-generator/jobconfig"
+)
+
+// TestReplaceVariableTables tests that only the content between the marker comments of a README is replaced
+func TestReplaceVariableTables(t *testing.T) {
+	testCases := []struct {
+		name          string
+		readme        string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Tables replaced",
+			readme:   "# Provider\n\n" + variableTablesBeginMarker + "\n\nold tables\n" + variableTablesEndMarker + "\n\n## Usage\n",
+			expected: "# Provider\n\n" + variableTablesBeginMarker + "\n\nnew tables\n\n" + variableTablesEndMarker + "\n\n## Usage\n",
+		},
+		{
+			name:     "Empty markers filled",
+			readme:   variableTablesBeginMarker + variableTablesEndMarker,
+			expected: variableTablesBeginMarker + "\n\nnew tables\n\n" + variableTablesEndMarker,
+		},
+		{
+			name:          "Missing end marker",
+			readme:        "# Provider\n\n" + variableTablesBeginMarker + "\n",
+			expectedError: "exactly once",
+		},
+		{
+			name:          "Duplicate markers",
+			readme:        strings.Repeat(variableTablesBeginMarker+"\n"+variableTablesEndMarker+"\n", 2),
+			expectedError: "exactly once",
+		},
+		{
+			name:          "Markers in the wrong order",
+			readme:        variableTablesEndMarker + "\n" + variableTablesBeginMarker + "\n",
+			expectedError: "must come before",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ReplaceVariableTables(tc.readme, "new tables\n")
+
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected an error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected README:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestRenderVariableTables tests the rows of the variable tables and the default values of the optional variables
+func TestRenderVariableTables(t *testing.T) {
+	commonJobConfig := &CommonJobConfig{CommonEnvVariables: []jobconfig.JobEnvVariable{
+		{Name: "SM_ACTION", Value: "type:string, required:true, description:The action."},
+	}}
+	userSchema := &jobconfig.JobConfig{JobEnvVariables: []jobconfig.JobEnvVariable{
+		{Name: "SMIN_USERNAME", Value: "type:string, required:true, description:The user name."},
+		{Name: "SMIN_SCOPE", Value: "type:string, required:false, default:user, description:The scope."},
+		{Name: "SMIN_TTL", Value: "type:integer, required:false, description:The lifetime."},
+		{Name: "SMIN_REFRESHABLE", Value: "type:boolean, required:false, description:Refreshable."},
+		{Name: "SMIN_AUDIENCE", Value: "type:string, required:false, description:The audience."},
+		{Name: "SMIN_KEY_ALGO", Value: "type:enum[RSA|ECDSA], required:false, default:RSA, description:The key algorithm."},
+		{Name: "SMOUT_TOKEN", Value: "type:string, required:true, description:The token."},
+	}}
+
+	tables := RenderVariableTables(commonJobConfig, userSchema)
+
+	expectedRows := []string{
+		"| `SM_ACTION`          | The action. |",
+		"| `SMIN_USERNAME`      | `string` | The user name. |",
+		"| `SMIN_SCOPE`         | `string`           | The scope.         | `user`        |",
+		"| `SMIN_TTL`           | `integer`          | The lifetime.      | `0`           |",
+		"| `SMIN_REFRESHABLE`   | `boolean`          | Refreshable.       | `false`       |",
+		"| `SMIN_AUDIENCE`      | `string`           | The audience.      | (empty)       |",
+		"| `SMIN_KEY_ALGO`      | `enum[RSA\\|ECDSA]` | The key algorithm. | `RSA`         |",
+		"| `SMOUT_TOKEN`        | `string` | The token.  |",
+	}
+	for _, expected := range expectedRows {
+		if !strings.Contains(tables, expected+"\n") {
+			t.Errorf("expected the tables to contain the row %q, got:\n%s", expected, tables)
+		}
+	}
+}
+
+// TestWriteMarkdownTable tests the padding and the escaping of the Markdown tables
+func TestWriteMarkdownTable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   []string
+		rows     [][]string
+		expected string
+	}{
+		{
+			name:     "No rows",
+			header:   []string{"Code", "Message"},
+			expected: "None.\n",
+		},
+		{
+			name:     "Columns padded to the widest cell",
+			header:   []string{"Code", "Message"},
+			rows:     [][]string{{"`ERR10001`", "Failed"}, {"`E1`", "Not implemented"}},
+			expected: "| Code       | Message         |\n|------------|-----------------|\n| `ERR10001` | Failed          |\n| `E1`       | Not implemented |\n",
+		},
+		{
+			name:     "Pipes escaped",
+			header:   []string{"Type"},
+			rows:     [][]string{{"enum[a|b]"}},
+			expected: "| Type       |\n|------------|\n| enum[a\\|b] |\n",
+		},
+		{
+			name:     "Width in runes",
+			header:   []string{"City"},
+			rows:     [][]string{{"Zürich"}},
+			expected: "| City   |\n|--------|\n| Zürich |\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var tableBuilder strings.Builder
+
+			writeMarkdownTable(&tableBuilder, tc.header, tc.rows)
+
+			if actual := tableBuilder.String(); actual != tc.expected {
+				t.Errorf("expected table:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
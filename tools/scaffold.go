@@ -0,0 +1,345 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"job-code-generator/jobconfig"
+)
+
+// Versions of the dependencies required by the generated code in a new provider module
+const (
+	scaffoldGoVersion        = "1.25.0"
+	scaffoldSDKCoreVersion   = "v5.23.2"
+	scaffoldSMSDKVersion     = "v2.0.22"
+	scaffoldValidatorVersion = "v9.31.0+incompatible"
+)
+
+// scaffoldData holds the values used to render the scaffolding templates
+type scaffoldData struct {
+	Module        string
+	Binary        string
+	Tables        string
+	ErrorCodes    string
+	PayloadFields []scaffoldPayloadField
+
+	GoVersion        string
+	SDKCoreVersion   string
+	SMSDKVersion     string
+	ValidatorVersion string
+}
+
+// scaffoldPayloadField is a CredentialsPayload field set by the provider skeleton
+type scaffoldPayloadField struct {
+	Name      string
+	ZeroValue string
+}
+
+// scaffoldTemplates maps the path of each scaffolded file, relative to the job directory, to its template
+var scaffoldTemplates = map[string]string{
+	"go.mod": `module {{.Module}}
+
+go {{.GoVersion}}
+
+require (
+	github.com/IBM/go-sdk-core/v5 {{.SDKCoreVersion}}
+	github.com/IBM/secrets-manager-go-sdk/v2 {{.SMSDKVersion}}
+	github.com/go-playground/validator {{.ValidatorVersion}}
+)
+`,
+
+	"cmd/main.go": `package main
+
+import (
+	"{{.Module}}/internal/job"
+)
+
+func main() {
+	job.Run(job.NewProvider())
+}
+`,
+
+	"internal/job/credentials_provider.go": `package job
+
+import (
+	"context"
+)
+
+// Provider creates and deletes the credentials in the target system.
+// The generated Run function handles the secret task, reports errors to Secrets Manager and deletes
+// the created credentials again if the task cannot be updated.
+type Provider struct{}
+
+// NewProvider creates a Provider
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+// Create creates new credentials in the target system and returns them with the ID that identifies them.
+// Report errors with the constructors generated from error_codes.json, e.g. NewNotImplementedError.
+func (p *Provider) Create(ctx context.Context, client SecretsManagerClient, config *Config) (CredentialsPayload, string, error) {
+	// TODO: create the credentials in the target system
+	credentialsPayload := CredentialsPayload{
+{{- range .PayloadFields}}
+		{{.Name}}: {{.ZeroValue}},
+{{- end}}
+	}
+	return credentialsPayload, "", NewNotImplementedError("Create")
+}
+
+// Delete deletes the credentials identified by config.SM_CREDENTIALS_ID from the target system
+func (p *Provider) Delete(ctx context.Context, client SecretsManagerClient, config *Config) error {
+	// TODO: delete the credentials from the target system
+	return NewNotImplementedError("Delete")
+}
+`,
+
+	"error_codes.json": scaffoldErrorCatalog,
+
+	"Dockerfile": `# Use official Golang image as a build stage
+FROM golang:{{goImageVersion .GoVersion}} AS builder
+
+# Set the working directory inside the container
+WORKDIR /app
+
+# Copy go.mod and go.sum to leverage Docker caching
+COPY go.mod go.sum ./
+RUN go mod download
+
+# Copy the entire project
+COPY . .
+
+# Ensure the binary is built statically (better compatibility)
+RUN CGO_ENABLED=0 GOOS=linux go build -o main cmd/main.go
+
+# Use a minimal image for the final container
+FROM alpine:latest
+
+# Set the working directory in the container
+WORKDIR /root/
+
+# Copy the built binary from the builder stage
+COPY --from=builder /app/main .
+
+# Run the binary
+CMD ["./main"]
+`,
+
+	".gitignore": `# Binaries
+/main
+/{{.Binary}}
+
+# Test and coverage output
+*.test
+*.out
+
+# Env file of the dry runs, which can contain credentials
+/.env
+`,
+
+	"README.md": `# IBM Cloud Secrets Manager Credentials Provider {{.Binary}}
+
+This is a Go application designed to run as an IBM Cloud Code Engine [job](https://cloud.ibm.com/docs/codeengine?topic=codeengine-job-plan) for IBM Cloud Secrets Manager [custom credentials](https://cloud.ibm.com/docs/secrets-manager?topic=secrets-manager-getting-started) secret type.
+
+## Overview
+
+When triggered by Secrets Manager, the job performs two main operations:
+
+* **Credentials Creation** - Generates new credentials.
+* **Credentials Deletion** - Deletes the previously created credentials.
+
+## Configuration
+
+### Environment Variables
+
+The job uses service and custom environment variables for configuration, which are automatically provided by Secrets Manager when the job is triggered.
+
+{{.Tables}}
+
+### Error Codes
+
+{{.ErrorCodes}}
+
+## Development
+
+### Project Structure
+
+` + "```" + `
+{{.Binary}}/
+├── cmd/
+│   └── main.go                     - Entry point for the application
+├── internal/
+│   └── job/
+│       ├── credentials_provider.go          - Implements the creation and deletion of the credentials
+│       ├── secrets_manager_job.go           - Handles integration with IBM Cloud Secrets Manager and runs the job
+│       ├── secrets_manager_job_fake_test.go - Fake Secrets Manager server for end-to-end tests
+│       └── secrets_manager_job_mock.go      - Test helpers for the integration with IBM Cloud Secrets Manager
+├── Dockerfile                      - Builds the job image
+├── error_codes.json                - Defines the error codes reported to Secrets Manager
+└── job_config.json                 - Defines the input and output parameters for the job
+` + "```" + `
+
+The ` + "`secrets_manager_job.go`" + `, ` + "`secrets_manager_job_mock.go`" + ` and ` + "`secrets_manager_job_fake_test.go`" + ` files are generated from ` + "`job_config.json`" + ` and ` + "`error_codes.json`" + ` by the [job-code-generator](../tools/README.md) tool. Regenerate them after editing these files.
+
+### Building and Testing
+
+` + "```bash" + `
+# Download the dependencies
+go mod tidy
+
+# Run test
+go test ./...
+
+# Build the job binary
+go build -o {{.Binary}} ./cmd
+` + "```" + `
+
+## Usage with IBM Cloud Secrets Manager
+
+Use the [job-deployer](../tools/README.md#job-deployer) tool to create the job in Code Engine from local source code:
+
+` + "```bash" + `
+../tools/job-deployer.sh --jobdir . --name <job_name> --action create
+` + "```" + `
+`,
+}
+
+// scaffoldErrorCatalog is the error catalog of a new provider, with the error code of its skeleton
+const scaffoldErrorCatalog = `{
+    "$schema": "../tools/error_codes.schema.json",
+    "error_codes": [
+        {
+            "code": "ERR10001",
+            "name": "NotImplemented",
+            "category": "internal",
+            "message": "{operation} is not implemented"
+        }
+    ]
+}
+`
+
+// runInit creates a new credentials provider module from a job configuration file
+func runInit(args []string) {
+	initFlags := flag.NewFlagSet("init", flag.ExitOnError)
+	jobDir := initFlags.String("jobdir", "", "Path to the new job project directory")
+	configPath := initFlags.String("config", "", "Optional path to the job configuration file to copy into the project (default: <jobdir>/job_config.json)")
+	moduleName := initFlags.String("module", "", "Optional Go module name (default: the name of the job directory)")
+	templatesDir := initFlags.String("templates", "", "Optional directory of *.tmpl files that override the embedded templates with the same name")
+	force := initFlags.Bool("force", false, "Overwrite existing files if set to true")
+	initFlags.Parse(args)
+
+	if *jobDir == "" {
+		fmt.Println("Usage: secrets-manager-job-generator init -jobdir=<job_directory> [-config=<job_config_file>] [-module=<module_name>] [-templates=<templates_directory>] [--force]")
+		os.Exit(1)
+	}
+	if *configPath == "" {
+		*configPath = filepath.Join(*jobDir, "job_config.json")
+	}
+	if *moduleName == "" {
+		absJobDir, err := filepath.Abs(*jobDir)
+		if err != nil {
+			fmt.Printf("Error resolving job directory: %v\n", err)
+			os.Exit(1)
+		}
+		*moduleName = filepath.Base(absJobDir)
+	}
+
+	userSchema, commonJobConfig := loadJobConfig(*configPath, false)
+	catalog := parseErrorCatalog(errorCatalogFileName, []byte(scaffoldErrorCatalog), userSchema)
+
+	// Generate the secrets manager job files so that the provider skeleton is wired to them
+	outputFiles := generateJobFiles(commonJobConfig, userSchema, catalog, filepath.Join(*jobDir, "internal", "job"), "job", *templatesDir)
+
+	// Copy the job configuration into the project unless it is already there
+	targetConfigPath := filepath.Join(*jobDir, "job_config.json")
+	if sameFile(*configPath, targetConfigPath) {
+		// The job configuration is already part of the project and is not overwritten
+	} else {
+		configData, err := os.ReadFile(*configPath)
+		if err != nil {
+			fmt.Printf("Error reading job configuration file: %v\n", err)
+			os.Exit(1)
+		}
+		outputFiles[targetConfigPath] = string(configData)
+	}
+
+	data := scaffoldData{
+		Module:           *moduleName,
+		Binary:           path.Base(*moduleName),
+		Tables:           markVariableTables(RenderVariableTables(commonJobConfig, userSchema)),
+		ErrorCodes:       markErrorCodeTable(RenderErrorCodeTable(jobErrorCodes(userSchema, catalog))),
+		PayloadFields:    scaffoldPayloadFields(userSchema),
+		GoVersion:        scaffoldGoVersion,
+		SDKCoreVersion:   scaffoldSDKCoreVersion,
+		SMSDKVersion:     scaffoldSMSDKVersion,
+		ValidatorVersion: scaffoldValidatorVersion,
+	}
+	funcs := template.FuncMap{
+		// goImageVersion returns the golang image tag of a go.mod version, e.g. 1.25 for 1.25.0
+		"goImageVersion": func(version string) string {
+			parts := strings.SplitN(version, ".", 3)
+			return strings.Join(parts[:min(len(parts), 2)], ".")
+		},
+	}
+	for filePath, text := range scaffoldTemplates {
+		tmpl := template.Must(template.New(filePath).Funcs(funcs).Parse(text))
+		var fileBuilder strings.Builder
+		if err := tmpl.Execute(&fileBuilder, data); err != nil {
+			fmt.Printf("Error rendering %s: %v\n", filePath, err)
+			os.Exit(1)
+		}
+		content := fileBuilder.String()
+		if strings.HasSuffix(filePath, ".go") {
+			formatted, err := format.Source([]byte(content))
+			if err != nil {
+				fmt.Printf("Error formatting %s: %v\n", filePath, err)
+				os.Exit(1)
+			}
+			content = string(formatted)
+		}
+		outputFiles[filepath.Join(*jobDir, filepath.FromSlash(filePath))] = content
+	}
+
+	writeFiles(outputFiles, *force)
+
+	fmt.Printf("Credentials provider created in %s. Next steps:\n", *jobDir)
+	fmt.Printf("  1. Run 'go mod tidy' in %s to download the dependencies\n", *jobDir)
+	fmt.Println("  2. Implement the Create and Delete methods of the Provider in internal/job/credentials_provider.go")
+	fmt.Println("  3. Declare the error codes of the provider in error_codes.json and regenerate the job files")
+}
+
+// scaffoldPayloadFields returns the CredentialsPayload fields with the zero value of their type
+func scaffoldPayloadFields(userSchema *jobconfig.JobConfig) []scaffoldPayloadField {
+	var fields []scaffoldPayloadField
+	for _, envVar := range userSchema.JobEnvVariables {
+		if !strings.HasPrefix(envVar.Name, "SMOUT_") {
+			continue
+		}
+		attrType, _, err := jobconfig.ParseAttributes(envVar.Value)
+		if err != nil {
+			continue
+		}
+		zeroValue := `""`
+		if mapType(attrType) != "string" {
+			zeroValue = "nil"
+		}
+		fields = append(fields, scaffoldPayloadField{
+			Name:      strings.ToUpper(strings.TrimPrefix(envVar.Name, "SMOUT_")),
+			ZeroValue: zeroValue,
+		})
+	}
+	return fields
+}
+
+// sameFile reports whether both paths refer to the same existing file
+func sameFile(path1, path2 string) bool {
+	info1, err1 := os.Stat(path1)
+	info2, err2 := os.Stat(path2)
+	return err1 == nil && err2 == nil && os.SameFile(info1, info2)
+}
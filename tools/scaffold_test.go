@@ -0,0 +1,154 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"job-code-generator/jobconfig"
+)
+
+// scaffoldJobConfig is the job configuration of the scaffolded provider in the tests
+const scaffoldJobConfig = `{
+    "job_env_variables": [
+        {"name": "SMIN_USERNAME", "value": "type:string, required:true, description:The user name."},
+        {"name": "SMOUT_TOKEN", "value": "type:string, required:true, description:The token."},
+        {"name": "SMOUT_EXPIRES_IN", "value": "type:integer, required:false, description:The lifetime of the token."}
+    ]
+}
+`
+
+// TestRunInit tests the files of a new provider module created from a job configuration
+func TestRunInit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		configInJobDir bool   // Whether the job configuration is already in the job directory
+		module         string // The -module flag, empty to derive the module from the job directory
+		expectedModule string
+		expectedBinary string
+	}{
+		{
+			name:           "Job configuration in the job directory",
+			configInJobDir: true,
+			expectedModule: "my-provider",
+			expectedBinary: "my-provider",
+		},
+		{
+			name:           "Job configuration copied into the job directory",
+			module:         "example.com/team/token-provider",
+			expectedModule: "example.com/team/token-provider",
+			expectedBinary: "token-provider",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobDir := filepath.Join(t.TempDir(), "my-provider")
+			if err := os.MkdirAll(jobDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			configPath := filepath.Join(t.TempDir(), "job_config.json")
+			if tc.configInJobDir {
+				configPath = filepath.Join(jobDir, "job_config.json")
+			}
+			if err := os.WriteFile(configPath, []byte(scaffoldJobConfig), 0644); err != nil {
+				t.Fatal(err)
+			}
+			args := []string{"-jobdir=" + jobDir, "-config=" + configPath}
+			if tc.module != "" {
+				args = append(args, "-module="+tc.module)
+			}
+
+			runInit(args)
+
+			expectedContents := map[string][]string{
+				"go.mod":                                        {"module " + tc.expectedModule + "\n", "go " + scaffoldGoVersion + "\n", "github.com/IBM/secrets-manager-go-sdk/v2 " + scaffoldSMSDKVersion},
+				"cmd/main.go":                                   {`"` + tc.expectedModule + `/internal/job"`, "job.Run(job.NewProvider())"},
+				"internal/job/credentials_provider.go":          {"TOKEN:      \"\",", "EXPIRES_IN: nil,", `NewNotImplementedError("Create")`},
+				"internal/job/secrets_manager_job.go":           {"package job"},
+				"internal/job/secrets_manager_job_mock.go":      {"type MockSecretsManagerClient struct"},
+				"internal/job/secrets_manager_job_fake_test.go": {"type FakeSecretsManagerServer struct"},
+				"error_codes.json":                              {`"code": "ERR10001"`},
+				"job_config.json":                               {`"SMOUT_TOKEN"`},
+				"Dockerfile":                                    {"FROM golang:1.25 AS builder"},
+				".gitignore":                                    {"/" + tc.expectedBinary + "\n", "/.env\n"},
+				"README.md": {
+					"# IBM Cloud Secrets Manager Credentials Provider " + tc.expectedBinary,
+					variableTablesBeginMarker, "`SMIN_USERNAME`", variableTablesEndMarker,
+					errorCodeTableBeginMarker, "`ERR10001`", errorCodeTableEndMarker,
+					"go build -o " + tc.expectedBinary + " ./cmd",
+				},
+			}
+			for filePath, expectedSubstrings := range expectedContents {
+				content, err := os.ReadFile(filepath.Join(jobDir, filepath.FromSlash(filePath)))
+				if err != nil {
+					t.Errorf("expected file %s to be created: %v", filePath, err)
+					continue
+				}
+				for _, expected := range expectedSubstrings {
+					if !strings.Contains(string(content), expected) {
+						t.Errorf("expected %s to contain %q, got:\n%s", filePath, expected, content)
+					}
+				}
+				if strings.HasSuffix(filePath, ".go") {
+					if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, parser.AllErrors); err != nil {
+						t.Errorf("expected %s to be valid Go code: %v", filePath, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestScaffoldPayloadFields tests that the provider skeleton sets every output variable to the zero value of its type
+func TestScaffoldPayloadFields(t *testing.T) {
+	testCases := []struct {
+		name       string
+		nameValues []string
+		expected   []scaffoldPayloadField
+	}{
+		{
+			name:       "String output",
+			nameValues: []string{"SMOUT_TOKEN", "type:string, required:true"},
+			expected:   []scaffoldPayloadField{{Name: "TOKEN", ZeroValue: `""`}},
+		},
+		{
+			name:       "Enum output",
+			nameValues: []string{"SMOUT_KIND", "type:enum[a|b], required:true"},
+			expected:   []scaffoldPayloadField{{Name: "KIND", ZeroValue: `""`}},
+		},
+		{
+			name:       "Integer, boolean and JSON outputs",
+			nameValues: []string{"SMOUT_TTL", "type:integer", "SMOUT_LOCKED", "type:boolean", "SMOUT_CLAIMS", "type:json"},
+			expected: []scaffoldPayloadField{
+				{Name: "TTL", ZeroValue: "nil"},
+				{Name: "LOCKED", ZeroValue: "nil"},
+				{Name: "CLAIMS", ZeroValue: "nil"},
+			},
+		},
+		{
+			name:       "Input variables are skipped",
+			nameValues: []string{"SMIN_USERNAME", "type:string", "SMOUT_TOKEN", "type:string"},
+			expected:   []scaffoldPayloadField{{Name: "TOKEN", ZeroValue: `""`}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userSchema := &jobconfig.JobConfig{}
+			for i := 0; i+1 < len(tc.nameValues); i += 2 {
+				userSchema.JobEnvVariables = append(userSchema.JobEnvVariables, jobconfig.JobEnvVariable{Name: tc.nameValues[i], Value: tc.nameValues[i+1]})
+			}
+
+			actual := scaffoldPayloadFields(userSchema)
+
+			if !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected fields %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
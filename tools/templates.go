@@ -0,0 +1,417 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"job-code-generator/jobconfig"
+)
+
+// embeddedTemplates holds the default templates of the generated files
+//
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// Templates that render the generated files. The other templates are included by these.
+const (
+	jobTemplateName           = "secrets_manager_job.go.tmpl"
+	jobMockTemplateName       = "secrets_manager_job_mock.go.tmpl"
+	jobFakeServerTemplateName = "secrets_manager_job_fake_test.go.tmpl"
+)
+
+// loadTemplates parses the embedded templates, then the *.tmpl files of overrideDir, if set.
+// A file in overrideDir replaces the embedded template with the same file name.
+func loadTemplates(overrideDir string) (*template.Template, error) {
+	templates, err := template.New("").Funcs(template.FuncMap{"join": strings.Join}).ParseFS(embeddedTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the embedded templates: %w", err)
+	}
+	if overrideDir == "" {
+		return templates, nil
+	}
+
+	overridePaths, err := filepath.Glob(filepath.Join(overrideDir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the templates in %s: %w", overrideDir, err)
+	}
+	if len(overridePaths) == 0 {
+		return nil, fmt.Errorf("no *.tmpl files found in %s", overrideDir)
+	}
+	for _, overridePath := range overridePaths {
+		name := filepath.Base(overridePath)
+		if templates.Lookup(name) == nil {
+			return nil, fmt.Errorf("template %s does not override any of the templates of the generator", overridePath)
+		}
+		content, err := os.ReadFile(overridePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read template: %w", err)
+		}
+		if _, err := templates.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("cannot parse template %s: %w", overridePath, err)
+		}
+	}
+	return templates, nil
+}
+
+// executeTemplate renders the named template with the given data
+func executeTemplate(templates *template.Template, name string, data any) (string, error) {
+	var fileBuilder strings.Builder
+	if err := templates.ExecuteTemplate(&fileBuilder, name, data); err != nil {
+		return "", fmt.Errorf("cannot execute template %s: %w", name, err)
+	}
+	return fileBuilder.String(), nil
+}
+
+// jobTemplateData is the data passed to the templates of the generated files
+type jobTemplateData struct {
+	PackageName     string
+	CommonVariables []commonVariableData
+	InputVariables  []inputVariableData
+	OutputVariables []outputVariableData
+	Enums           []enumData
+	Patterns        []patternData
+	SecretResolvers []secretResolverData
+	ErrorCodes      []errorCodeData
+	MaxPayloadSize  int // The maximum size of the serialized CredentialsPayload, in bytes
+}
+
+// commonVariableData describes a common SM_ variable passed by Secrets Manager
+type commonVariableData struct {
+	Name     string
+	GoType   string // string, or the named type of an enum variable, e.g. Trigger for SM_TRIGGER
+	Required bool
+}
+
+// inputVariableData describes an SMIN_ variable and the Config field it is loaded into
+type inputVariableData struct {
+	Name        string // The variable name in job_config.json, e.g. SMIN_EXPIRATION_DAYS
+	FieldName   string // The Config field name, e.g. SM_EXPIRATION_DAYS
+	EnvVarName  string // The environment variable set by Secrets Manager, e.g. SM_EXPIRATION_DAYS_VALUE
+	Type        string // The declared type, e.g. integer
+	GoType      string
+	Required    bool
+	HasDefault  bool
+	Default     string
+	ParseCall   string // The expression that converts value to GoType, empty for strings
+	Checks      []constraintCheckData
+	ValidateTag string // The validate tag of the declared constraints, e.g. omitempty,min=1,max=825
+}
+
+// constraintCheckData is a check of a declared constraint in ConfigFromEnv
+type constraintCheckData struct {
+	Condition     string // The Go condition that is true if the constraint is violated
+	MessageFormat string // The quoted format of the error message, with a %s verb for the value
+}
+
+// outputVariableData describes an SMOUT_ variable and its CredentialsPayload field
+type outputVariableData struct {
+	Name        string
+	FieldName   string
+	GoType      string
+	JSONTag     string
+	ValidateTag string
+}
+
+// secretResolverData describes the generated resolver of a secret_id input variable
+type secretResolverData struct {
+	Name        string   // The variable name, e.g. SMIN_LOGIN_SECRET_ID
+	FieldName   string   // The Config field that holds the secret ID, e.g. SM_LOGIN_SECRET_ID
+	FuncName    string   // The resolver name, e.g. ResolveLoginSecretId
+	SecretTypes []string // The allowed secret types, all the supported types if not declared
+	Field       string   // The field to extract from the secret, empty if not declared
+}
+
+// enumData describes the named type generated for an enum input variable
+type enumData struct {
+	Variable      string
+	TypeName      string
+	Options       []enumOptionData
+	AllowedValues string // The options in declaration order, separated by commas
+	ConstNames    string // The constant names in declaration order, separated by commas
+}
+
+// enumOptionData is an option of an enum input variable and its constant name
+type enumOptionData struct {
+	ConstName string
+	Value     string
+}
+
+// errorCodeData describes an error code of the catalog and its generated constant and constructor
+type errorCodeData struct {
+	jobconfig.ErrorCode
+	ConstName     string // The code constant, e.g. ErrRoleNotCreated
+	CategoryConst string // The ErrorCategory constant, e.g. ErrorCategoryUpstream
+	FuncName      string // The constructor, e.g. NewRoleNotCreatedError, empty for the builtin codes
+	Params        string // The parameters of the constructor, e.g. schema string, err error
+	Args          string // The arguments of the message format, e.g. schema, err, empty if the message has no placeholders
+	MessageFormat string // The quoted format of the message
+	HasErr        bool   // The message has the {error} placeholder, so the constructor wraps an error
+}
+
+// patternData is a declared pattern, keyed by struct and field name, e.g. Config.SM_COUNTRY
+type patternData struct {
+	Key     string
+	Pattern string
+}
+
+// newJobTemplateData builds the template data from the common and user job configurations and the error catalog
+func newJobTemplateData(commonJobConfig *CommonJobConfig, userSchema *jobconfig.JobConfig, catalog *jobconfig.ErrorCatalog, packageName string) (*jobTemplateData, error) {
+	data := &jobTemplateData{PackageName: packageName, MaxPayloadSize: jobconfig.MaxPayloadSize}
+
+	for _, envVar := range commonJobConfig.CommonEnvVariables {
+		attrType, validations, err := jobconfig.ParseAttributes(envVar.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse attributes '%s' for common variable '%s': %w", envVar.Value, envVar.Name, err)
+		}
+		common := commonVariableData{
+			Name:     strings.TrimSpace(envVar.Name),
+			GoType:   "string",
+			Required: validations["required"] == "true",
+		}
+		if jobconfig.IsEnumType(attrType) {
+			// The type is named after the variable without the SM_ prefix, e.g. Trigger for SM_TRIGGER
+			common.GoType = jobconfig.EnumTypeName(strings.TrimPrefix(common.Name, "SM_"))
+			data.Enums = append(data.Enums, newEnumData(common.Name, common.GoType, attrType))
+		}
+		data.CommonVariables = append(data.CommonVariables, common)
+	}
+
+	for _, envVar := range userSchema.JobEnvVariables {
+		attrType, validations, err := jobconfig.ParseAttributes(envVar.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse attributes '%s' for variable '%s': %w", envVar.Value, envVar.Name, err)
+		}
+		constraints, err := jobconfig.ParseConstraints(validations)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse constraints '%s' for variable '%s': %w", envVar.Value, envVar.Name, err)
+		}
+		required := validations["required"] == "true"
+
+		switch {
+		case strings.HasPrefix(envVar.Name, "SMIN_"):
+			input := newInputVariableData(envVar.Name, attrType, validations, constraints)
+			data.InputVariables = append(data.InputVariables, input)
+			if jobconfig.IsEnumType(attrType) {
+				data.Enums = append(data.Enums, newEnumData(envVar.Name, jobconfig.EnumTypeName(envVar.Name), attrType))
+			}
+			if constraints.Pattern != "" {
+				data.Patterns = append(data.Patterns, patternData{Key: "Config." + input.FieldName, Pattern: constraints.Pattern})
+			}
+			if attrType == "secret_id" {
+				data.SecretResolvers = append(data.SecretResolvers, newSecretResolverData(envVar.Name, input.FieldName, validations))
+			}
+
+		case strings.HasPrefix(envVar.Name, "SMOUT_"):
+			output := newOutputVariableData(envVar.Name, attrType, required, constraints)
+			data.OutputVariables = append(data.OutputVariables, output)
+			if constraints.Pattern != "" {
+				data.Patterns = append(data.Patterns, patternData{Key: "CredentialsPayload." + output.FieldName, Pattern: constraints.Pattern})
+			}
+		}
+	}
+
+	for _, errorCode := range jobErrorCodes(userSchema, catalog) {
+		data.ErrorCodes = append(data.ErrorCodes, newErrorCodeData(errorCode, slices.Contains(catalog.ErrorCodes, errorCode)))
+	}
+	return data, nil
+}
+
+// newErrorCodeData describes the constant of an error code and, for the codes of the catalog, its constructor.
+// The constructor has a string parameter for each placeholder of the message and an error parameter for {error}.
+func newErrorCodeData(errorCode jobconfig.ErrorCode, withConstructor bool) errorCodeData {
+	data := errorCodeData{
+		ErrorCode:     errorCode,
+		ConstName:     jobconfig.ErrorConstName(errorCode.Name),
+		CategoryConst: "ErrorCategory" + jobconfig.EnumTypeName(errorCode.Category),
+		MessageFormat: strconv.Quote(errorCode.Message),
+	}
+	if !withConstructor {
+		return data
+	}
+
+	data.FuncName = jobconfig.ErrorConstructorName(errorCode.Name)
+	var params, args []string
+	for _, parameter := range jobconfig.MessageParameters(errorCode.Message) {
+		if parameter == jobconfig.ErrorParameterName {
+			data.HasErr = true
+			continue
+		}
+		params = append(params, parameter+" string")
+	}
+	if data.HasErr {
+		params = append(params, "err error")
+	}
+	for _, placeholder := range jobconfig.MessagePlaceholders(errorCode.Message) {
+		if placeholder == jobconfig.ErrorParameterName {
+			placeholder = "err"
+		}
+		args = append(args, placeholder)
+	}
+	if len(args) > 0 {
+		data.MessageFormat = strconv.Quote(jobconfig.MessageFormat(errorCode.Message))
+	}
+	data.Params = strings.Join(params, ", ")
+	data.Args = strings.Join(args, ", ")
+	return data
+}
+
+// newInputVariableData describes how ConfigFromEnv loads an SMIN_ variable
+func newInputVariableData(name, attrType string, validations map[string]string, constraints jobconfig.Constraints) inputVariableData {
+	input := inputVariableData{
+		Name:       name,
+		FieldName:  "SM_" + strings.TrimPrefix(name, "SMIN_"),
+		EnvVarName: "SM_" + strings.TrimPrefix(name, "SMIN_") + "_VALUE",
+		Type:       attrType,
+		GoType:     "string",
+		Required:   validations["required"] == "true",
+	}
+	input.Default, input.HasDefault = validations["default"]
+
+	// Assign the value to the typed field, using the parser of the declared type
+	switch {
+	case attrType == "integer":
+		input.GoType = "int"
+		input.ParseCall = fmt.Sprintf("parseInteger(%q, value)", name)
+	case attrType == "boolean":
+		input.GoType = "bool"
+		input.ParseCall = fmt.Sprintf("parseBoolean(%q, value)", name)
+	case attrType == "string_list":
+		input.GoType = "[]string"
+		input.ParseCall = fmt.Sprintf("parseStringList(%q, value)", name)
+	case attrType == "json":
+		input.GoType = "json.RawMessage"
+		input.ParseCall = fmt.Sprintf("parseJSON(%q, value)", name)
+	case attrType == "duration":
+		input.GoType = "time.Duration"
+		input.ParseCall = fmt.Sprintf("parseDuration(%q, value)", name)
+	case attrType == "url":
+		input.GoType = "*url.URL"
+		input.ParseCall = fmt.Sprintf("parseURL(%q, value)", name)
+	case jobconfig.IsEnumType(attrType):
+		input.GoType = jobconfig.EnumTypeName(name)
+		input.ParseCall = fmt.Sprintf("Parse%s(value)", input.GoType)
+	}
+
+	input.Checks = constraintChecks(input.FieldName, name, constraints)
+
+	// ConfigFromEnv checks the constraints with the messages above. The validate tag declares them on the Config field
+	// too, as on the CredentialsPayload fields. An optional variable that is not set keeps the zero value of its type.
+	if tags := constraints.ValidateTags(); len(tags) > 0 {
+		if !input.Required {
+			tags = append([]string{"omitempty"}, tags...)
+		}
+		input.ValidateTag = strings.Join(tags, ",")
+	}
+	return input
+}
+
+// newOutputVariableData describes the CredentialsPayload field of an SMOUT_ variable
+func newOutputVariableData(name, attrType string, required bool, constraints jobconfig.Constraints) outputVariableData {
+	fieldName := strings.TrimPrefix(name, "SMOUT_")
+	output := outputVariableData{
+		Name:      name,
+		FieldName: strings.ToUpper(fieldName),
+		GoType:    mapType(attrType),
+		JSONTag:   strings.ToLower(fieldName),
+	}
+
+	// Build validate tag from the declared constraints. Strings are always limited to the maximum output length.
+	var tags []string
+	switch {
+	case output.GoType != "string":
+		// Pointer and JSON fields are nil until they are set, and are left out of the payload if they are optional
+		if required {
+			tags = append(tags, "required")
+		} else {
+			tags = append(tags, "omitempty")
+			output.JSONTag += ",omitempty"
+		}
+		if attrType == "json" {
+			tags = append(tags, "json")
+		}
+	default:
+		// An optional string can be left empty, whatever its format and constraints
+		if required {
+			tags = append(tags, "required")
+		} else {
+			tags = append(tags, "omitempty")
+		}
+		if attrType == "secret_id" {
+			tags = append(tags, "uuid")
+		} else if jobconfig.IsEnumType(attrType) {
+			tags = append(tags, "oneof="+strings.Join(jobconfig.EnumOptions(attrType), " "))
+		}
+		if constraints.MaxLen == nil {
+			maxLen := jobconfig.MaxOutputLength
+			constraints.MaxLen = &maxLen
+		}
+	}
+	tags = append(tags, constraints.ValidateTags()...)
+	output.ValidateTag = strings.Join(tags, ",")
+	return output
+}
+
+// newSecretResolverData describes the resolver of a secret_id input variable from its 'secret_types' and 'field' attributes
+func newSecretResolverData(name, fieldName string, validations map[string]string) secretResolverData {
+	resolver := secretResolverData{
+		Name:        name,
+		FieldName:   fieldName,
+		FuncName:    jobconfig.SecretResolverName(name),
+		SecretTypes: jobconfig.SecretTypes,
+		Field:       validations["field"],
+	}
+	if secretTypes, ok := validations["secret_types"]; ok {
+		resolver.SecretTypes = jobconfig.SecretTypeOptions(secretTypes)
+	}
+	return resolver
+}
+
+// newEnumData describes the named type of an enum variable
+func newEnumData(name, typeName, attrType string) enumData {
+	enum := enumData{
+		Variable: name,
+		TypeName: typeName,
+	}
+	options := jobconfig.EnumOptions(attrType)
+	constNames := make([]string, len(options))
+	for i, option := range options {
+		constNames[i] = jobconfig.EnumConstName(enum.TypeName, option)
+		enum.Options = append(enum.Options, enumOptionData{ConstName: constNames[i], Value: option})
+	}
+	enum.AllowedValues = strings.Join(options, ", ")
+	enum.ConstNames = strings.Join(constNames, ", ")
+	return enum
+}
+
+// constraintChecks returns the checks of the declared constraints for a Config field in ConfigFromEnv
+func constraintChecks(fieldName, variableName string, c jobconfig.Constraints) []constraintCheckData {
+	var checks []constraintCheckData
+	check := func(condition, message string) {
+		format := fmt.Sprintf("invalid value '%%s' for %s. %s", variableName, strings.ReplaceAll(message, "%", "%%"))
+		checks = append(checks, constraintCheckData{Condition: condition, MessageFormat: strconv.Quote(format)})
+	}
+
+	field := "config." + fieldName
+	if c.Min != nil {
+		check(fmt.Sprintf("%s < %d", field, *c.Min), fmt.Sprintf("must be at least %d", *c.Min))
+	}
+	if c.Max != nil {
+		check(fmt.Sprintf("%s > %d", field, *c.Max), fmt.Sprintf("must be at most %d", *c.Max))
+	}
+	if c.MinLen != nil {
+		check(fmt.Sprintf("len([]rune(%s)) < %d", field, *c.MinLen), fmt.Sprintf("must be at least %d characters long", *c.MinLen))
+	}
+	if c.MaxLen != nil {
+		check(fmt.Sprintf("len([]rune(%s)) > %d", field, *c.MaxLen), fmt.Sprintf("must be at most %d characters long", *c.MaxLen))
+	}
+	if c.Pattern != "" {
+		check(fmt.Sprintf("!fieldPatterns[\"Config.%s\"].MatchString(%s)", fieldName, field), fmt.Sprintf("must match the pattern '%s'", c.Pattern))
+	}
+	return checks
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"job-code-generator/jobconfig"
+)
+
+// TestLoadTemplates tests that the templates of an override directory replace the embedded templates with the same name
+func TestLoadTemplates(t *testing.T) {
+	testCases := []struct {
+		name             string
+		overrideFiles    map[string]string // The files of the override directory, no directory if nil
+		expectedFakeCode string            // A substring of the rendered fake server code
+		expectedError    string
+	}{
+		{
+			name:             "Embedded templates",
+			expectedFakeCode: "type FakeSecretsManagerServer struct",
+		},
+		{
+			name:             "Included template overridden",
+			overrideFiles:    map[string]string{"fake_server.go.tmpl": "// Custom fake server\n"},
+			expectedFakeCode: "// Custom fake server\n",
+		},
+		{
+			name:             "Rendered template overridden",
+			overrideFiles:    map[string]string{jobFakeServerTemplateName: "package {{.PackageName}}\n\n// Custom test file\n"},
+			expectedFakeCode: "package job\n\n// Custom test file\n",
+		},
+		{
+			name:          "Empty directory",
+			overrideFiles: map[string]string{},
+			expectedError: "no *.tmpl files found",
+		},
+		{
+			name:          "Unknown template",
+			overrideFiles: map[string]string{"fake_server.tmpl": "// Custom fake server\n"},
+			expectedError: "does not override any of the templates of the generator",
+		},
+		{
+			name:          "Invalid template",
+			overrideFiles: map[string]string{"fake_server.go.tmpl": "{{.PackageName"},
+			expectedError: "cannot parse template",
+		},
+		{
+			name:          "Other files ignored",
+			overrideFiles: map[string]string{"README.md": "# Templates\n"},
+			expectedError: "no *.tmpl files found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			overrideDir := ""
+			if tc.overrideFiles != nil {
+				overrideDir = t.TempDir()
+				for name, content := range tc.overrideFiles {
+					if err := os.WriteFile(filepath.Join(overrideDir, name), []byte(content), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			templates, err := loadTemplates(overrideDir)
+
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected an error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			code, err := GenerateFakeServerCode(templates, "job")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(code, tc.expectedFakeCode) {
+				t.Errorf("expected the fake server code to contain %q, got:\n%s", tc.expectedFakeCode, code)
+			}
+			// The templates that are not overridden are still the embedded ones
+			mockCode, err := GenerateMockCode(templates, "job")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(mockCode, "type MockSecretsManagerClient struct") {
+				t.Errorf("expected the embedded mock template, got:\n%s", mockCode)
+			}
+		})
+	}
+}
+
+// TestReservedIdentifiers tests that the reserved identifiers of the job configuration validation are the exported
+// top-level identifiers declared by the templates, other than those generated for the variables and the error codes
+func TestReservedIdentifiers(t *testing.T) {
+	var commonJobConfig *CommonJobConfig
+	if err := json.Unmarshal([]byte(builtinJobConfig), &commonJobConfig); err != nil {
+		t.Fatal(err)
+	}
+	userSchema := &jobconfig.JobConfig{JobEnvVariables: []jobconfig.JobEnvVariable{
+		{Name: "SMIN_KEY_ALGO", Value: "type:enum[RSA|ECDSA], required:true"},
+		{Name: "SMIN_LOGIN", Value: "type:secret_id, required:true"},
+		{Name: "SMOUT_TOKEN", Value: "type:string, required:true"},
+	}}
+	catalog := &jobconfig.ErrorCatalog{ErrorCodes: []jobconfig.ErrorCode{
+		{Code: "ERR10001", Name: "RoleNotCreated", Category: "upstream", Message: "failed"},
+	}}
+	// The identifiers generated for the variables and the error codes of the job, and for the builtin error codes
+	generated := []string{"KeyAlgo", "KeyAlgoRSA", "KeyAlgoECDSA", "ParseKeyAlgo", "ResolveLogin", "ErrRoleNotCreated", "NewRoleNotCreatedError"}
+	for _, builtin := range jobconfig.BuiltinErrorCodes {
+		generated = append(generated, "Err"+builtin.Name)
+	}
+
+	templates, err := loadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := GenerateCode(templates, commonJobConfig, userSchema, catalog, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockCode, err := GenerateMockCode(templates, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeServerCode, err := GenerateFakeServerCode(templates, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	declared := make(map[string]bool)
+	for _, content := range []string{code, mockCode, fakeServerCode} {
+		file, err := parser.ParseFile(token.NewFileSet(), "", content, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					declared[decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						declared[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							declared[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	var expected []string
+	for _, identifier := range slices.Sorted(maps.Keys(declared)) {
+		if token.IsExported(identifier) && !slices.Contains(generated, identifier) {
+			expected = append(expected, identifier)
+		}
+	}
+
+	if !slices.Equal(jobconfig.ReservedIdentifiers, expected) {
+		t.Errorf("expected the reserved identifiers %q, got %q", expected, jobconfig.ReservedIdentifiers)
+	}
+}
+
+// TestNewOutputVariableData tests the validate tag of the CredentialsPayload field of an output variable
+func TestNewOutputVariableData(t *testing.T) {
+	testCases := []struct {
+		name                string
+		value               string
+		expectedValidateTag string
+	}{
+		{
+			name:                "Required string with constraints",
+			value:               "type:string, required:true, minlen:8, pattern:^[a-z]+$",
+			expectedValidateTag: "required,min=8,max=100000,pattern",
+		},
+		{
+			name:                "Optional string with constraints",
+			value:               "type:string, required:false, minlen:8, pattern:^[a-z]+$",
+			expectedValidateTag: "omitempty,min=8,max=100000,pattern",
+		},
+		{
+			name:                "Optional string with a maximum length",
+			value:               "type:string, required:false, maxlen:64",
+			expectedValidateTag: "omitempty,max=64",
+		},
+		{
+			name:                "Optional secret ID",
+			value:               "type:secret_id, required:false",
+			expectedValidateTag: "omitempty,uuid,max=100000",
+		},
+		{
+			name:                "Required enum",
+			value:               "type:enum[A|B], required:true",
+			expectedValidateTag: "required,oneof=A B,max=100000",
+		},
+		{
+			name:                "Optional integer with a range",
+			value:               "type:integer, required:false, min:1, max:10",
+			expectedValidateTag: "omitempty,min=1,max=10",
+		},
+		{
+			name:                "Required JSON",
+			value:               "type:json, required:true",
+			expectedValidateTag: "required,json",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attrType, validations, err := jobconfig.ParseAttributes(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			constraints, err := jobconfig.ParseConstraints(validations)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output := newOutputVariableData("SMOUT_VALUE", attrType, validations["required"] == "true", constraints)
+
+			if output.ValidateTag != tc.expectedValidateTag {
+				t.Errorf("expected validate tag %q, got %q", tc.expectedValidateTag, output.ValidateTag)
+			}
+		})
+	}
+}
+
+// TestNewInputVariableData tests the validate tag of the Config field of an input variable
+func TestNewInputVariableData(t *testing.T) {
+	testCases := []struct {
+		name                string
+		value               string
+		expectedValidateTag string
+	}{
+		{
+			name:                "Required string with constraints",
+			value:               "type:string, required:true, minlen:2, maxlen:64, pattern:^[a-z]+$",
+			expectedValidateTag: "min=2,max=64,pattern",
+		},
+		{
+			name:                "Optional integer with a range",
+			value:               "type:integer, required:false, default:90, min:1, max:825",
+			expectedValidateTag: "omitempty,min=1,max=825",
+		},
+		{
+			name:  "No constraints",
+			value: "type:string, required:false",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attrType, validations, err := jobconfig.ParseAttributes(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			constraints, err := jobconfig.ParseConstraints(validations)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := newInputVariableData("SMIN_VALUE", attrType, validations, constraints)
+
+			if input.ValidateTag != tc.expectedValidateTag {
+				t.Errorf("expected validate tag %q, got %q", tc.expectedValidateTag, input.ValidateTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"job-code-generator/jobconfig"
+)
+
+// runValidate implements the "validate" subcommand, which checks a job configuration file against the
+// contract with Secrets Manager. It is used by the job deployer before it creates or updates a job.
+func runValidate(args []string) {
+	validateFlags := flag.NewFlagSet("validate", flag.ExitOnError)
+	jobDir := validateFlags.String("jobdir", "", "Path to the job project directory")
+	configPath := validateFlags.String("config", "", "Path to the job configuration file (default: <jobdir>/job_config.json)")
+	validateFlags.Parse(args)
+
+	if *jobDir == "" && *configPath == "" {
+		fmt.Println("Usage: secrets-manager-job-generator validate (-jobdir=<job_directory> | -config=<job_config_file>)")
+		os.Exit(1)
+	}
+	if *configPath == "" {
+		*configPath = filepath.Join(*jobDir, "job_config.json")
+	}
+
+	data, err := os.ReadFile(*configPath)
+	if err != nil {
+		fmt.Printf("Error reading job configuration file: %v\n", err)
+		os.Exit(1)
+	}
+	userSchema, diagnostics := jobconfig.Parse(data)
+	if len(diagnostics) > 0 {
+		printDiagnostics(*configPath, diagnostics)
+		os.Exit(1)
+	}
+	fmt.Printf("Job configuration %s is valid.\n", *configPath)
+
+	if *jobDir == "" {
+		return
+	}
+	catalogPath := filepath.Join(*jobDir, errorCatalogFileName)
+	if _, err := os.Stat(catalogPath); err == nil {
+		loadErrorCatalog(catalogPath, userSchema)
+		fmt.Printf("Error catalog %s is valid.\n", catalogPath)
+	}
+}
+
+// printDiagnostics prints the diagnostics of a job configuration file, prefixed with its path
+func printDiagnostics(configPath string, diagnostics []jobconfig.Diagnostic) {
+	fmt.Printf("Invalid job configuration. Found %d validation errors:\n", len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		fmt.Printf("%s:%s\n", configPath, diagnostic)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change in a unified diff
+const diffContextLines = 3
+
+// verifyGeneratedFile compares the generated code with the file at outputPath and prints a unified diff if they differ.
+// It returns false if the file is missing or out of date.
+func verifyGeneratedFile(outputPath, code string) bool {
+	existing, err := os.ReadFile(outputPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("File %s does not exist. Run the generator to create it.\n", outputPath)
+		return false
+	}
+	if err != nil {
+		fmt.Printf("Error reading file: %v\n", err)
+		return false
+	}
+
+	diff := UnifiedDiff(outputPath, outputPath+" (generated)", string(existing), code)
+	if diff != "" {
+		fmt.Printf("File %s is out of date with job_config.json or was edited by hand:\n%s", outputPath, diff)
+		return false
+	}
+	return true
+}
+
+// diffOp is a single line of an edit script produced by diffLines
+type diffOp struct {
+	kind byte // ' ' for an unchanged line, '-' for a removed line and '+' for an added line
+	line string
+}
+
+// UnifiedDiff returns a unified diff that turns the old content into the new content, or an empty string if they are equal
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+	ops := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var diffBuilder strings.Builder
+	diffBuilder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// Group the changes that are close to each other into hunks with a few lines of context
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	for first := 0; first < len(changes); {
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContextLines {
+			last++
+		}
+		hunkStart := max(changes[first]-diffContextLines, 0)
+		hunkEnd := min(changes[last]+1+diffContextLines, len(ops))
+		writeHunk(&diffBuilder, ops, hunkStart, hunkEnd)
+		first = last + 1
+	}
+	if len(changes) == 0 {
+		diffBuilder.WriteString("\\ The files differ only in their line endings\n")
+	}
+	return diffBuilder.String()
+}
+
+// writeHunk writes the edit script lines between start and end as a unified diff hunk
+func writeHunk(diffBuilder *strings.Builder, ops []diffOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	diffBuilder.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount)))
+	for _, op := range ops[start:end] {
+		diffBuilder.WriteString(fmt.Sprintf("%c%s\n", op.kind, op.line))
+	}
+}
+
+// hunkRange formats the line range of a hunk header
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// diffLines computes the shortest edit script between two lists of lines using their longest common subsequence
+func diffLines(oldLines, newLines []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			ops = append(ops, diffOp{' ', oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', oldLines[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		ops = append(ops, diffOp{'-', oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		ops = append(ops, diffOp{'+', newLines[j]})
+	}
+	return ops
+}
+
+// splitLines splits the content into lines without their line endings
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
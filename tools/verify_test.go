@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns the lines "<prefix>1" to "<prefix>n", each followed by a newline
+func numberedLines(prefix string, n int) string {
+	var lines strings.Builder
+	for i := 1; i <= n; i++ {
+		lines.WriteString(prefix + strconv.Itoa(i) + "\n")
+	}
+	return lines.String()
+}
+
+// TestUnifiedDiff tests the hunks and the headers of the unified diff printed by the verify mode
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name       string
+		oldContent string
+		newContent string
+		expected   string
+	}{
+		{
+			name:       "Equal contents",
+			oldContent: "a\nb\n",
+			newContent: "a\nb\n",
+			expected:   "",
+		},
+		{
+			name:       "Changed line",
+			oldContent: "a\nb\nc\n",
+			newContent: "a\nB\nc\n",
+			expected:   "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:       "Lines added to an empty file",
+			oldContent: "",
+			newContent: "a\n",
+			expected:   "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:       "All lines removed",
+			oldContent: "a\nb\n",
+			newContent: "",
+			expected:   "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:       "Distant changes in separate hunks",
+			oldContent: numberedLines("l", 10),
+			newContent: "L1\n" + numberedLines("l", 9)[len("l1\n"):] + "L10\n",
+			expected: "--- old\n+++ new\n" +
+				"@@ -1,4 +1,4 @@\n-l1\n+L1\n l2\n l3\n l4\n" +
+				"@@ -7,4 +7,4 @@\n l7\n l8\n l9\n-l10\n+L10\n",
+		},
+		{
+			name:       "Close changes in one hunk",
+			oldContent: numberedLines("l", 8),
+			newContent: "l1\nL2\nl3\nl4\nl5\nl6\nL7\nl8\n",
+			expected:   "--- old\n+++ new\n@@ -1,8 +1,8 @@\n l1\n-l2\n+L2\n l3\n l4\n l5\n l6\n-l7\n+L7\n l8\n",
+		},
+		{
+			name:       "Line endings only",
+			oldContent: "a\n",
+			newContent: "a",
+			expected:   "--- old\n+++ new\n\\ The files differ only in their line endings\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := UnifiedDiff("old", "new", tc.oldContent, tc.newContent)
+
+			if actual != tc.expected {
+				t.Errorf("expected diff:\n%s\ngot:\n%s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestDiffLines tests that the edit script keeps the longest common subsequence of lines unchanged
+func TestDiffLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		oldLines []string
+		newLines []string
+		expected []string // The edit script, as the kind of each operation followed by its line
+	}{
+		{
+			name: "No lines",
+		},
+		{
+			name:     "Inserted line",
+			oldLines: []string{"a", "c"},
+			newLines: []string{"a", "b", "c"},
+			expected: []string{" a", "+b", " c"},
+		},
+		{
+			name:     "Removed line",
+			oldLines: []string{"a", "b", "c"},
+			newLines: []string{"a", "c"},
+			expected: []string{" a", "-b", " c"},
+		},
+		{
+			name:     "Moved line",
+			oldLines: []string{"a", "b", "c"},
+			newLines: []string{"b", "c", "a"},
+			expected: []string{"-a", " b", " c", "+a"},
+		},
+		{
+			name:     "No common lines",
+			oldLines: []string{"a", "b"},
+			newLines: []string{"c"},
+			expected: []string{"-a", "-b", "+c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, op := range diffLines(tc.oldLines, tc.newLines) {
+				actual = append(actual, string(op.kind)+op.line)
+			}
+
+			if !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected edit script %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestVerifyGeneratedFile tests that the verify mode only accepts an existing file with the generated content
+func TestVerifyGeneratedFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing string // The content of the existing file, empty if the file does not exist
+		expected bool
+	}{
+		{
+			name:     "Up to date",
+			existing: "package job\n",
+			expected: true,
+		},
+		{
+			name:     "Edited by hand",
+			existing: "package job\n\n// edited\n",
+			expected: false,
+		},
+		{
+			name:     "Missing file",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "secrets_manager_job.go")
+			if tc.existing != "" {
+				if err := os.WriteFile(outputPath, []byte(tc.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if actual := verifyGeneratedFile(outputPath, "package job\n"); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}